@@ -0,0 +1,220 @@
+package engine
+
+import "math/bits"
+
+// bitboards hold the position as one bit per square, A1 is bit 0 and H8 bit 63
+type bitboards struct {
+	// indexed by White and Black, then by piece kind
+	pieces [2][King + 1]uint64
+	colors [2]uint64
+}
+
+// directions of the rays, the first four are the rook directions
+const (
+	rayNorth = iota
+	raySouth
+	rayEast
+	rayWest
+	rayNorthEast
+	rayNorthWest
+	raySouthEast
+	raySouthWest
+)
+
+var (
+	oppositeDirection = [8]int{raySouth, rayNorth, rayWest, rayEast, raySouthWest, raySouthEast, rayNorthWest, rayNorthEast}
+
+	knightAttacks [64]uint64
+	kingAttacks   [64]uint64
+	// indexed by White and Black
+	pawnAttacks [2][64]uint64
+	rays        [8][64]uint64
+	// squares between two squares on a common line, without both ends
+	between [64][64]uint64
+	// the full line through two squares on a common line
+	lines [64][64]uint64
+)
+
+func init() {
+	for sq := 0; sq < 64; sq++ {
+		from := to0x88(sq)
+
+		for _, delta := range deltaKnight {
+			if to := from + delta; uint8(to)&0x88 == 0 {
+				knightAttacks[sq] |= bit(to)
+			}
+		}
+		for _, delta := range deltaKing {
+			if to := from + delta; uint8(to)&0x88 == 0 {
+				kingAttacks[sq] |= bit(to)
+			}
+		}
+		for side, pawn := range []int8{WhitePawn, BlackPawn} {
+			for _, delta := range []int8{pawn*nextRank - 1, pawn*nextRank + 1} {
+				if to := from + delta; uint8(to)&0x88 == 0 {
+					pawnAttacks[side][sq] |= bit(to)
+				}
+			}
+		}
+
+		deltas := []int8{moveUp, moveDown, moveRight, moveLeft, moveUpRight, moveUpLeft, moveDownRight, moveDownLeft}
+		for direction, delta := range deltas {
+			for to := from + delta; uint8(to)&0x88 == 0; to += delta {
+				rays[direction][sq] |= bit(to)
+			}
+		}
+	}
+
+	for from := 0; from < 64; from++ {
+		for direction := range rays {
+			ray := rays[direction][from]
+			for targets := ray; targets != 0; targets &= targets - 1 {
+				to := bits.TrailingZeros64(targets)
+				between[from][to] = ray &^ rays[direction][to] &^ (uint64(1) << to)
+				lines[from][to] = ray | rays[oppositeDirection[direction]][from] | uint64(1)<<from
+			}
+		}
+	}
+
+	initMagics(&rookMagics, rookTable[:], rookDirections, &rookMagicNumbers)
+	initMagics(&bishopMagics, bishopTable[:], bishopDirections, &bishopMagicNumbers)
+}
+
+// to64 converts a 0x88 square into a bitboard square
+func to64(sq int8) int {
+	return int(sq+sq&7) >> 1
+}
+
+// to0x88 converts a bitboard square into a 0x88 square
+func to0x88(sq int) int8 {
+	return int8(sq + sq&^7)
+}
+
+// bit returns the bitboard of a single 0x88 square
+func bit(sq int8) uint64 {
+	return uint64(1) << to64(sq)
+}
+
+// popLowest returns the lowest square of a bitboard as 0x88 square and clears it
+func popLowest(bb *uint64) int8 {
+	sq := bits.TrailingZeros64(*bb)
+	*bb &= *bb - 1
+	return to0x88(sq)
+}
+
+// sideIndex returns the bitboard index of a color
+func sideIndex(color int8) int {
+	if color == Black {
+		return 1
+	}
+	return 0
+}
+
+// squaresOf lists the squares of a bitboard in ascending order
+func squaresOf(bb uint64) []Square {
+	squares := []Square{}
+	for bb != 0 {
+		squares = append(squares, Square(popLowest(&bb)))
+	}
+	return squares
+}
+
+// newBitboards computes the bitboards of the pieces on the board
+func newBitboards(b *Board) bitboards {
+	bb := bitboards{}
+	for sq := int8(0); sq < boardSize; sq++ {
+		if b.legalSquare(sq) && b.data[sq] != Empty {
+			bb.toggle(b.data[sq], sq)
+		}
+	}
+	return bb
+}
+
+// toggle adds or removes a piece on a square
+func (bb *bitboards) toggle(piece int8, sq int8) {
+	side := 0
+	if piece < 0 {
+		side = 1
+	}
+	mask := bit(sq)
+	bb.pieces[side][abs(piece)] ^= mask
+	bb.colors[side] ^= mask
+}
+
+// move plays or takes back a move, toggling is its own inverse
+func (bb *bitboards) move(m Move) {
+	changes := changesOf(m)
+	bb.apply(&changes)
+}
+
+// apply toggles the pieces of the changes of a move
+func (bb *bitboards) apply(changes *pieceChanges) {
+	for _, c := range changes.all() {
+		bb.toggle(c.piece, c.sq)
+	}
+}
+
+func (bb *bitboards) occupied() uint64 {
+	return bb.colors[0] | bb.colors[1]
+}
+
+// slidingAttacks follows the rays of the directions up to the first blocker,
+// the magic tables are generated from it
+func slidingAttacks(sq int, occupied uint64, directions []int) uint64 {
+	attacks := uint64(0)
+	for _, direction := range directions {
+		ray := rays[direction][sq]
+		if blockers := ray & occupied; blockers != 0 {
+			// the rays to the north and east grow with the square
+			blocker := bits.TrailingZeros64(blockers)
+			if direction == raySouth || direction == rayWest || direction == raySouthEast || direction == raySouthWest {
+				blocker = 63 - bits.LeadingZeros64(blockers)
+			}
+			ray &^= rays[direction][blocker]
+		}
+		attacks |= ray
+	}
+	return attacks
+}
+
+var (
+	rookDirections   = []int{rayNorth, raySouth, rayEast, rayWest}
+	bishopDirections = []int{rayNorthEast, rayNorthWest, raySouthEast, raySouthWest}
+)
+
+// attackersTo returns the pieces of a side attacking a square with the given occupancy
+func (bb *bitboards) attackersTo(sq int, side int, occupied uint64) uint64 {
+	pieces := &bb.pieces[side]
+	attackers := knightAttacks[sq] & pieces[Knight]
+	attackers |= kingAttacks[sq] & pieces[King]
+	attackers |= pawnAttacks[1-side][sq] & pieces[Pawn]
+	attackers |= rookAttacks(sq, occupied) & (pieces[Rook] | pieces[Queen])
+	attackers |= bishopAttacks(sq, occupied) & (pieces[Bishop] | pieces[Queen])
+	return attackers
+}
+
+// attacked checks whether a side attacks a square
+func (bb *bitboards) attacked(sq int, side int) bool {
+	return bb.attackersTo(sq, side, bb.occupied()) != 0
+}
+
+// pinned returns the pieces of a side that shield its king from a slider
+func (bb *bitboards) pinned(king int, us, them int) uint64 {
+	occupied := bb.occupied()
+	enemies := bb.colors[them]
+
+	snipers := rookAttacks(king, enemies) & (bb.pieces[them][Rook] | bb.pieces[them][Queen])
+	snipers |= bishopAttacks(king, enemies) & (bb.pieces[them][Bishop] | bb.pieces[them][Queen])
+
+	pinned := uint64(0)
+	for snipers != 0 {
+		sniper := bits.TrailingZeros64(snipers)
+		snipers &= snipers - 1
+
+		blockers := between[king][sniper] & occupied
+		if bits.OnesCount64(blockers) == 1 {
+			pinned |= blockers & bb.colors[us]
+		}
+	}
+	return pinned
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"math/bits"
+	"testing"
+)
+
+func TestSquareConversion(t *testing.T) {
+	for sq := 0; sq < 64; sq++ {
+		if to64(to0x88(sq)) != sq {
+			t.Errorf("Expected square %d to convert back\n", sq)
+		}
+	}
+	if to64(int8(H8)) != 63 || to0x88(8) != int8(A2) {
+		t.Error("Expected A1 to be bit 0 and H8 bit 63")
+	}
+}
+
+func TestSlidingAttacks(t *testing.T) {
+	occupied := bit(int8(D6)) | bit(int8(F4)) | bit(int8(B2))
+
+	if attacks := rookAttacks(to64(int8(D4)), occupied); bits.OnesCount64(attacks) != 10 || attacks&bit(int8(D6)) == 0 || attacks&bit(int8(D7)) != 0 {
+		t.Errorf("Expected the rook to stop at its blockers but found %x\n", attacks)
+	}
+	if attacks := bishopAttacks(to64(int8(D4)), occupied); bits.OnesCount64(attacks) != 12 || attacks&bit(int8(B2)) == 0 || attacks&bit(int8(A1)) != 0 {
+		t.Errorf("Expected the bishop to stop at its blockers but found %x\n", attacks)
+	}
+}
+
+func TestBetweenAndLines(t *testing.T) {
+	if between[to64(int8(A1))][to64(int8(D4))] != bit(int8(B2))|bit(int8(C3)) {
+		t.Error("Expected b2 and c3 between a1 and d4")
+	}
+	if between[to64(int8(A1))][to64(int8(B3))] != 0 || lines[to64(int8(A1))][to64(int8(B3))] != 0 {
+		t.Error("Expected nothing between squares off a common line")
+	}
+	if lines[to64(int8(C3))][to64(int8(E5))] != lines[to64(int8(H8))][to64(int8(A1))] {
+		t.Error("Expected the long diagonal through c3 and e5")
+	}
+}
+
+func TestBitboardsFollowMoves(t *testing.T) {
+	b := NewBoard(position2FEN)
+	for _, move := range NewGenerator(b).GenerateMoves() {
+		b.MakeMove(move)
+		if b.bitboards != newBitboards(b) {
+			t.Errorf("Expected the bitboards to match the board after %s\n", move.UciString())
+		}
+		b.UndoMove()
+	}
+	if b.bitboards != newBitboards(b) {
+		t.Error("Expected the bitboards to be restored by undo")
+	}
+}
+
+func TestGenerateAllPromotions(t *testing.T) {
+	b := NewBoard("4k3/1P6/8/8/8/8/8/4K3 w - - 0 1")
+
+	promotions := map[int8]bool{}
+	for _, move := range NewGenerator(b).GenerateMoves() {
+		if move.Special == movePromotion {
+			promotions[move.Promoted] = true
+		}
+	}
+	if len(promotions) != 4 {
+		t.Errorf("Expected four promotions but found %v\n", promotions)
+	}
+}
+
+func TestCapturedRookLosesCastleRight(t *testing.T) {
+	b := NewBoard("r3k2r/8/8/8/8/8/6B1/R3K2R w KQkq - 0 1")
+	move, err := b.LegalMove("g2a8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b.MakeMove(move)
+	if b.blackCastle != castleShort || b.whiteCastle != castleShort|castleLong {
+		t.Errorf("Expected black to keep only the short castle but found %d\n", b.blackCastle)
+	}
+}
@@ -21,6 +21,7 @@ type HistoryItem struct {
 	enPassant     Square
 	halfMoveClock int
 	hash          int64
+	pawnHash      int64
 }
 
 // Board represents a chessboard
@@ -36,23 +37,44 @@ type Board struct {
 	blackCastle       int8
 	whiteKingPosition Square
 	blackKingPosition Square
-	status            int
 	zobristTable      *ZobristTable
 	currentHash       int64
+	pawnHash          int64
+	bitboards         bitboards
+	network           *networkState
+	eval              boardEval
 }
 
 // NewBoard creates a new chessboard from given fen
 func NewBoard(fen string) *Board {
-	b, err := parseFEN(fen)
+	b, err := newBoard(fen)
 
 	if err != nil {
 		fmt.Printf("invalid FEN: \"%s\"\n", fen)
 	}
 
-	b.zobristTable = NewZobristTable()
+	return b
+}
+
+// newBoard parses the fen and sets up the hashes, the board is usable even on errors
+func newBoard(fen string) (*Board, error) {
+	b, err := parseFEN(fen)
+
+	b.zobristTable = zobristKeys
 	b.currentHash = b.generateHash()
+	b.pawnHash = b.generatePawnHash()
 
-	return b
+	return b, err
+}
+
+// Clone returns a copy of the board that shares nothing a move changes with the original
+func (b *Board) Clone() *Board {
+	c := *b
+	c.history = append([]HistoryItem(nil), b.history...)
+	if b.network != nil {
+		c.network = b.network.clone()
+	}
+	return &c
 }
 
 func (b *Board) legalSquare(square int8) bool {
@@ -78,102 +100,41 @@ func (b *Board) MakeMove(m Move) {
 		b.fullMoves++
 	}
 
-	switch m.Special {
-	case moveOrdinary:
-		b.data[m.From] = Empty
-		b.data[m.To] = m.MovedPiece
+	changes := changesOf(m)
+	b.place(&changes)
 
-		if m.Content != Empty {
-			b.halfMoveClock = 0
-		}
-
-		switch m.MovedPiece {
-		case WhiteKing:
-			b.whiteKingPosition = m.To
-			if m.From == whiteKingStartSquare {
-				b.whiteCastle = castleNone
-			}
-		case BlackKing:
-			b.blackKingPosition = m.To
-			if m.From == blackKingStartSquare {
-				b.blackCastle = castleNone
-			}
-		case WhiteRook:
-			if m.From == whiteRookShortSquare {
-				b.whiteCastle &= ^castleShort
-			} else if m.From == whiteRookLongSquare {
-				b.whiteCastle &= ^castleLong
-			}
-		case BlackRook:
-			if m.From == blackRookShortSquare {
-				b.blackCastle &= ^castleShort
-			} else if m.From == whiteRookLongSquare {
-				b.blackCastle &= ^castleLong
-			}
-		case WhitePawn:
-			b.halfMoveClock = 0
-			steps := rank(int8(m.To)) - rank(int8(m.From))
-
-			if steps > 1 {
-				b.enPassant = Square(int8(m.From) + nextRank)
-			}
-		case BlackPawn:
-			b.halfMoveClock = 0
-			steps := rank(int8(m.From)) - rank(int8(m.To))
-
-			if steps > 1 {
-				b.enPassant = Square(int8(m.From) - nextRank)
-			}
-		}
+	switch abs(m.MovedPiece) {
+	case King:
+		b.setKingPosition(m.MovedPiece, m.To)
+	case Pawn:
+		b.halfMoveClock = 0
 
-	case moveCastelingShort:
-		// king
-		b.data[m.To] = m.MovedPiece
-		b.data[m.From] = Empty
-		// rook
-		rookPos := int8(m.From) + castleShortDistanceRook*nextFile
-		b.data[int8(m.From)+nextFile] = b.data[rookPos]
-		b.data[rookPos] = Empty
-		if m.MovedPiece == WhiteKing {
-			b.whiteCastle = castleNone
-			b.whiteKingPosition = m.To
-		} else {
-			b.blackCastle = castleNone
-			b.blackKingPosition = m.To
+		// a double step allows an en passant capture on the square it passed
+		if m.To-m.From == 2*Square(nextRank) || m.From-m.To == 2*Square(nextRank) {
+			b.enPassant = (m.From + m.To) / 2
 		}
-	case moveCastelingLong:
-		// king
-		b.data[m.To] = m.MovedPiece
-		b.data[m.From] = Empty
-		// rook
-		rookPos := int8(m.From) - castleLongDistanceRook*nextFile
-		b.data[int8(m.From)-nextFile] = b.data[rookPos]
-		b.data[rookPos] = Empty
-		if m.MovedPiece == WhiteKing {
-			b.whiteCastle = castleNone
-			b.whiteKingPosition = m.To
-		} else {
-			b.blackCastle = castleNone
-			b.blackKingPosition = m.To
-		}
-	case movePromotion:
-		b.data[m.From] = Empty
-		b.data[m.To] = m.Promoted
-		b.halfMoveClock = 0
-	case moveEnPassant:
-		b.data[m.From] = Empty
-		b.data[m.To] = m.MovedPiece
-		b.data[int8(m.To)-m.MovedPiece*nextRank] = Empty
+	}
+	if m.Content != Empty {
 		b.halfMoveClock = 0
 	}
 
+	b.updateCastleRights(m)
+	b.bitboards.apply(&changes)
+
 	b.sideToMove = opponent(b.sideToMove)
 	b.ply++
 
 	historyItem.hash = b.currentHash
+	historyItem.pawnHash = b.pawnHash
 	b.history = append(b.history, historyItem)
 
-	b.updateHash(m)
+	b.updateHash(&changes, historyItem)
+	b.updatePawnHash(&changes)
+	b.eval.apply(&changes, 1)
+
+	if b.network != nil {
+		b.network.push(m)
+	}
 }
 
 // UndoMove undoes the last move on the board
@@ -191,48 +152,15 @@ func (b *Board) UndoMove() {
 	b.blackCastle = historyItem.blackCastle
 	b.enPassant = historyItem.enPassant
 	b.halfMoveClock = historyItem.halfMoveClock
+	b.currentHash = historyItem.hash
+	b.pawnHash = historyItem.pawnHash
 
 	m := historyItem.move
+	changes := changesOf(m)
+	b.takeBack(&changes)
 
-	// XXX: might to be done at the end?
-	b.updateHash(m)
-
-	switch {
-	case m.Special == moveOrdinary || m.Special == movePromotion:
-		b.data[m.To] = m.Content
-		b.data[m.From] = m.MovedPiece
-		switch m.MovedPiece {
-		case WhiteKing:
-			b.whiteKingPosition = m.From
-		case BlackKing:
-			b.blackKingPosition = m.From
-		}
-	case m.Special == moveCastelingShort:
-		b.data[m.From] = m.MovedPiece
-		b.data[int8(m.From)+castleShortDistanceRook*nextFile] = b.data[int8(m.From)+nextFile]
-		b.data[m.To] = Empty
-		b.data[int8(m.From)+nextFile] = Empty
-		switch m.MovedPiece {
-		case WhiteKing:
-			b.whiteKingPosition = m.From
-		case BlackKing:
-			b.blackKingPosition = m.From
-		}
-	case m.Special == moveCastelingLong:
-		b.data[m.From] = m.MovedPiece
-		b.data[int8(m.From)-castleLongDistanceRook*nextFile] = b.data[int8(m.From)-nextFile]
-		b.data[m.To] = Empty
-		b.data[int8(m.From)-nextFile] = Empty
-		switch m.MovedPiece {
-		case WhiteKing:
-			b.whiteKingPosition = m.From
-		case BlackKing:
-			b.blackKingPosition = m.From
-		}
-	case m.Special == moveEnPassant:
-		b.data[m.From] = m.MovedPiece
-		b.data[m.To] = Empty
-		b.data[int8(m.To)-m.MovedPiece*nextRank] = m.Content
+	if abs(m.MovedPiece) == King {
+		b.setKingPosition(m.MovedPiece, m.From)
 	}
 
 	b.sideToMove = opponent(b.sideToMove)
@@ -242,63 +170,85 @@ func (b *Board) UndoMove() {
 		b.fullMoves--
 	}
 
+	b.bitboards.apply(&changes)
+	b.eval.apply(&changes, -1)
+	if b.network != nil {
+		b.network.pop()
+	}
 }
 
-func (b *Board) isEmpty(squares ...Square) bool {
-	for _, s := range squares {
-		if b.data[uint8(s)] != Empty {
-			return false
+// place empties the squares the changes of a move remove pieces from and puts the
+// placed pieces on theirs
+func (b *Board) place(changes *pieceChanges) {
+	for _, c := range changes.all() {
+		if c.sign < 0 {
+			b.data[c.sq] = Empty
+		} else {
+			b.data[c.sq] = c.piece
 		}
-	} 
-	return true
+	}
 }
 
-func (b *Board) repetitions() int {
-	r := 0
-	first := len(b.history) - b.halfMoveClock
-	if first >= 0 {
-		for i := first; i < len(b.history)-1; i++ {
-			if b.history[i].hash == b.currentHash {
-				r++
-			}
+// takeBack undoes the changes of a move in reverse order
+func (b *Board) takeBack(changes *pieceChanges) {
+	list := changes.all()
+	for i := len(list) - 1; i >= 0; i-- {
+		if c := list[i]; c.sign < 0 {
+			b.data[c.sq] = c.piece
+		} else {
+			b.data[c.sq] = Empty
 		}
 	}
-	return r
 }
 
-func (b *Board) updateHash(m Move) {
-	key := b.currentHash
-	color := 0
-	if b.sideToMove == Black {
-		color = 1
+func (b *Board) setKingPosition(king int8, sq Square) {
+	if king == WhiteKing {
+		b.whiteKingPosition = sq
+	} else {
+		b.blackKingPosition = sq
 	}
-	piece := abs(m.MovedPiece) - 1
-	key ^= b.zobristTable.hashPieces[piece][color][int8(m.From)]
-	key ^= b.zobristTable.hashPieces[piece][color][int8(m.To)]
+}
 
-	if m.Content != Empty {
-		key ^= b.zobristTable.hashPieces[abs(m.Content)-1][color][int8(m.To)]
+// updateCastleRights removes the rights of kings and rooks that move or rooks that are captured
+func (b *Board) updateCastleRights(m Move) {
+	for _, sq := range []Square{m.From, m.To} {
+		switch sq {
+		case whiteKingStartSquare:
+			b.whiteCastle = castleNone
+		case whiteRookShortSquare:
+			b.whiteCastle &^= castleShort
+		case whiteRookLongSquare:
+			b.whiteCastle &^= castleLong
+		case blackKingStartSquare:
+			b.blackCastle = castleNone
+		case blackRookShortSquare:
+			b.blackCastle &^= castleShort
+		case blackRookLongSquare:
+			b.blackCastle &^= castleLong
+		}
 	}
+}
 
-	switch m.Special {
-	case moveCastelingLong:
-		if color == 0 {
-			key ^= b.zobristTable.hashCastelingWhite[castleLong]
-		} else {
-			key ^= b.zobristTable.hashCastelingBlack[castleLong]
-		}
-	case moveCastelingShort:
-		if color == 0 {
-			key ^= b.zobristTable.hashCastelingWhite[castleShort]
-		} else {
-			key ^= b.zobristTable.hashCastelingBlack[castleLong]
-		}
-	case moveEnPassant:
-		key ^= b.zobristTable.hashEnPassant[int8(m.To)-int8(m.MovedPiece)*nextRank]
-	case movePromotion:
-		key ^= b.zobristTable.hashPromotion[abs(m.Promoted)-1]
+// updateHash updates the hash by the pieces the move changes, the castle rights and the
+// en passant square before and after the move and the side to move
+func (b *Board) updateHash(changes *pieceChanges, before HistoryItem) {
+	z := b.zobristTable
+	key := b.currentHash
+
+	for _, c := range changes.all() {
+		key ^= b.pieceKey(c.piece, c.sq)
 	}
 
+	key ^= z.hashCastelingWhite[before.whiteCastle] ^ z.hashCastelingWhite[b.whiteCastle]
+	key ^= z.hashCastelingBlack[before.blackCastle] ^ z.hashCastelingBlack[b.blackCastle]
+	if before.enPassant != Invalid {
+		key ^= z.hashEnPassant[before.enPassant]
+	}
+	if b.enPassant != Invalid {
+		key ^= z.hashEnPassant[b.enPassant]
+	}
+	key ^= z.hashSide
+
 	b.currentHash = key
 }
 
@@ -306,19 +256,128 @@ func (b *Board) generateHash() int64 {
 	key := int64(0)
 
 	for square := int8(0); square < boardSize; square++ {
-		piece := b.data[square]
-		if piece > Empty {
-			key ^= b.zobristTable.hashPieces[piece-1][0][square]
-		} else if piece < Empty {
-			key ^= b.zobristTable.hashPieces[-piece-1][1][square]
+		if b.legalSquare(square) && b.data[square] != Empty {
+			key ^= b.pieceKey(b.data[square], square)
 		}
 	}
 
 	key ^= b.zobristTable.hashCastelingWhite[b.whiteCastle]
 	key ^= b.zobristTable.hashCastelingBlack[b.blackCastle]
+	if b.enPassant != Invalid {
+		key ^= b.zobristTable.hashEnPassant[b.enPassant]
+	}
 	if b.sideToMove == Black {
 		key ^= b.zobristTable.hashSide
 	}
 
 	return key
 }
+
+// updatePawnHash updates the hash of the pawns only, used by the pawn structure cache
+func (b *Board) updatePawnHash(changes *pieceChanges) {
+	for _, c := range changes.all() {
+		if abs(c.piece) == Pawn {
+			b.pawnHash ^= b.pieceKey(c.piece, c.sq)
+		}
+	}
+}
+
+func (b *Board) generatePawnHash() int64 {
+	key := int64(0)
+
+	for square := int8(0); square < boardSize; square++ {
+		if abs(b.data[square]) == Pawn {
+			key ^= b.pieceKey(b.data[square], square)
+		}
+	}
+
+	return key
+}
+
+func (b *Board) pieceKey(piece int8, square int8) int64 {
+	color := 0
+	if piece < 0 {
+		color = 1
+	}
+	return b.zobristTable.hashPieces[abs(piece)-1][color][square]
+}
+
+// PieceAt returns the piece on a square, Empty for empty squares and squares off the board
+func (b *Board) PieceAt(sq Square) int8 {
+	if !b.legalSquare(int8(sq)) {
+		return Empty
+	}
+	return b.data[sq]
+}
+
+// SideToMove returns White or Black
+func (b *Board) SideToMove() int8 {
+	return b.sideToMove
+}
+
+// CastlingRights returns the castling rights in FEN notation, like "KQkq" or "-"
+func (b *Board) CastlingRights() string {
+	rights := ""
+	if b.whiteCastle&castleShort != 0 {
+		rights += "K"
+	}
+	if b.whiteCastle&castleLong != 0 {
+		rights += "Q"
+	}
+	if b.blackCastle&castleShort != 0 {
+		rights += "k"
+	}
+	if b.blackCastle&castleLong != 0 {
+		rights += "q"
+	}
+	if rights == "" {
+		return "-"
+	}
+	return rights
+}
+
+// EnPassant returns the square a pawn may capture en passant on, or Invalid
+func (b *Board) EnPassant() Square {
+	return b.enPassant
+}
+
+// HalfMoveClock returns the plies since the last capture or pawn move
+func (b *Board) HalfMoveClock() int {
+	return b.halfMoveClock
+}
+
+// FullMoveNumber returns the number of the move, it starts at 1 and grows after black moved
+func (b *Board) FullMoveNumber() int {
+	return b.fullMoves
+}
+
+// FEN returns the position in Forsyth-Edwards notation
+func (b *Board) FEN() string {
+	return generateFEN(b)
+}
+
+// Hash returns the Zobrist hash of the position
+func (b *Board) Hash() int64 {
+	return b.currentHash
+}
+
+// History returns a copy of the moves played on the board, the first move first
+func (b *Board) History() []Move {
+	moves := make([]Move, len(b.history))
+	for i, item := range b.history {
+		moves[i] = item.move
+	}
+	return moves
+}
+
+// Status returns the state of the game: "normal", "check", "white_mates", "black_mates",
+// "stalemate" or "draw" by the fifty move rule, a threefold repetition or insufficient
+// material
+func (b *Board) Status() string {
+	return statusNames[b.gameStatus()]
+}
+
+// LegalMoves returns the legal moves of the side to move
+func (b *Board) LegalMoves() []Move {
+	return NewGenerator(b).GenerateMoves()
+}
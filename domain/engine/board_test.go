@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBoardAccessors(t *testing.T) {
+	fen := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w Kkq - 3 12"
+	b := NewBoard(fen)
+
+	if b.FEN() != fen {
+		t.Errorf("Expected the FEN %s but found %s\n", fen, b.FEN())
+	}
+	if b.PieceAt(E1) != WhiteKing || b.PieceAt(A6) != BlackBishop || b.PieceAt(E3) != Empty || b.PieceAt(Invalid) != Empty {
+		t.Error("Expected the pieces of the position")
+	}
+	if b.SideToMove() != White || b.CastlingRights() != "Kkq" || b.EnPassant() != Invalid {
+		t.Errorf("Expected white to move with Kkq but found %d and %s\n", b.SideToMove(), b.CastlingRights())
+	}
+	if b.HalfMoveClock() != 3 || b.FullMoveNumber() != 12 {
+		t.Errorf("Expected the clocks 3 and 12 but found %d and %d\n", b.HalfMoveClock(), b.FullMoveNumber())
+	}
+	if len(b.LegalMoves()) != 47 {
+		t.Errorf("Expected 47 legal moves but found %d\n", len(b.LegalMoves()))
+	}
+}
+
+func TestBoardHistoryAndHash(t *testing.T) {
+	b := NewBoard(defaultFEN)
+	start := b.Hash()
+
+	played := []Move{}
+	for _, str := range []string{"e2e4", "c7c5", "g1f3"} {
+		move, err := b.LegalMove(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.MakeMove(move)
+		played = append(played, move)
+	}
+
+	history := b.History()
+	if !reflect.DeepEqual(history, played) {
+		t.Errorf("Expected the history %v but found %v\n", played, history)
+	}
+	history[0] = Move{}
+	if b.History()[0] != played[0] {
+		t.Error("Expected the history to be a copy")
+	}
+
+	if b.EnPassant() != Invalid || b.Hash() == start || b.Hash() != NewBoard(b.FEN()).Hash() {
+		t.Error("Expected the hash to follow the position")
+	}
+}
+
+func TestBoardStatus(t *testing.T) {
+	tests := []struct {
+		fen      string
+		expected string
+	}{
+		{defaultFEN, "normal"},
+		{"4k3/4r3/8/8/8/8/8/4K3 w - - 0 1", "check"},
+		{"rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", "black_mates"},
+		{"7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", "stalemate"},
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 100 80", "draw"},
+		{"4k3/8/8/8/8/8/8/2B1K3 w - - 0 1", "draw"},
+	}
+
+	for _, test := range tests {
+		if status := NewBoard(test.fen).Status(); status != test.expected {
+			t.Errorf("Expected %s but found %s in %s\n", test.expected, status, test.fen)
+		}
+	}
+}
+
+func TestBoardStatusRepetition(t *testing.T) {
+	b := NewBoard(defaultFEN)
+	for i := 0; i < 2; i++ {
+		for _, str := range []string{"g1f3", "g8f6", "f3g1", "f6g8"} {
+			if b.Status() == "draw" {
+				t.Fatalf("Expected no draw before the third repetition at %s\n", str)
+			}
+			move, _ := b.LegalMove(str)
+			b.MakeMove(move)
+		}
+	}
+
+	if b.Status() != "draw" {
+		t.Errorf("Expected a draw by threefold repetition but found %s\n", b.Status())
+	}
+}
+
+func TestHashFollowsMoves(t *testing.T) {
+	for _, fen := range []string{position2FEN, "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1"} {
+		b := NewBoard(fen)
+		start := b.Hash()
+
+		for _, move := range b.LegalMoves() {
+			b.MakeMove(move)
+			for _, reply := range b.LegalMoves() {
+				b.MakeMove(reply)
+				if b.Hash() != NewBoard(b.FEN()).Hash() {
+					t.Fatalf("Expected the hash of %s after %s %s\n", b.FEN(), move.UciString(), reply.UciString())
+				}
+				b.UndoMove()
+			}
+			b.UndoMove()
+		}
+
+		if b.Hash() != start {
+			t.Error("Expected undo to restore the hash")
+		}
+	}
+}
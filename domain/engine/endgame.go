@@ -0,0 +1,286 @@
+package engine
+
+const (
+	// a known win scores above any material advantage but below a mate
+	evalKnownWin = 5000
+	// a known win reached by a pawn move or a capture in the search loses this per ply
+	// to it, so that converting now beats converting later
+	evalConvertDelay = 20
+
+	evalPushToEdge   = 20
+	evalPushToCorner = 40
+	evalPushClose    = 10
+	evalPushPawn     = 20
+
+	// the endgame score of the strong side is scaled by factor/scaleNormal
+	scaleNormal                = 64
+	scaleDraw                  = 0
+	scaleOppositeBishops       = 16
+	scaleOppositeBishopsPieces = 48
+	scaleNoPawnsMinor          = 4
+	scaleNoPawns               = 14
+)
+
+// materialCount counts the pieces of White and Black by their kind
+type materialCount [2][King + 1]int
+
+func (m *materialCount) nonPawnMaterial(side int) int {
+	value := 0
+	for kind := Knight; kind <= Queen; kind++ {
+		value += m[side][kind] * pieceValueMiddle[kind]
+	}
+	return value
+}
+
+func (m *materialCount) pieces(side int) int {
+	return m[side][Knight] + m[side][Bishop] + m[side][Rook] + m[side][Queen]
+}
+
+func (m *materialCount) bare(side int) bool {
+	return m[side][Pawn] == 0 && m.pieces(side) == 0
+}
+
+// cannotWin checks whether a side lacks the material to ever mate
+func (m *materialCount) cannotWin(side int) bool {
+	if m[side][Pawn] > 0 || m[side][Rook] > 0 || m[side][Queen] > 0 {
+		return false
+	}
+	minors := m[side][Knight] + m[side][Bishop]
+	return minors <= 1 || m[side][Knight] == 2 && m[side][Bishop] == 0 && m.bare(1-side)
+}
+
+// evaluateEndgame scores known endgames from white's point of view by the material of the
+// sides. It returns false if the endgame is not known.
+func evaluateEndgame(b *Board, m *materialCount, p *EvalParams) (int, bool) {
+	if m.cannotWin(0) && m.cannotWin(1) {
+		return scoreDraw, true
+	}
+
+	for strong := 0; strong < 2; strong++ {
+		if !m.bare(1 - strong) {
+			continue
+		}
+
+		score, known := 0, false
+		switch {
+		case m.pieces(strong) == 0 && m[strong][Pawn] == 1:
+			score, known = evaluateKPK(b, strong, p)
+		case m.pieces(strong) == 2 && m[strong][Pawn] == 0 && m[strong][Bishop] == 1 && m[strong][Knight] == 1:
+			score, known = evaluateKBNK(b, strong, m, p), true
+		case !m.cannotWin(strong) && m.nonPawnMaterial(strong) >= rookValue:
+			score, known = evaluateKXK(b, strong, m, p), true
+		}
+
+		if !known {
+			return 0, false
+		}
+		if strong == 1 {
+			score = -score
+		}
+		return score, true
+	}
+
+	return 0, false
+}
+
+// kingSquares returns the kings of the strong and the weak side
+func kingSquares(b *Board, strong int) (int8, int8) {
+	if strong == 0 {
+		return int8(b.whiteKingPosition), int8(b.blackKingPosition)
+	}
+	return int8(b.blackKingPosition), int8(b.whiteKingPosition)
+}
+
+// centerDistance is zero in the center and six in the corners
+func centerDistance(sq int8) int {
+	edge := func(v int8) int {
+		if v < size/2 {
+			return int(size/2 - 1 - v)
+		}
+		return int(v - size/2)
+	}
+	return edge(rank(sq)) + edge(file(sq))
+}
+
+// evaluateKXK drives the lone king to the edge with the strong king close to it
+func evaluateKXK(b *Board, strong int, m *materialCount, p *EvalParams) int {
+	strongKing, weakKing := kingSquares(b, strong)
+
+	score := p.KnownWin + m.nonPawnMaterial(strong) + m[strong][Pawn]*pawnValueEnd
+	score += p.PushToEdge * centerDistance(weakKing)
+	score += p.PushClose * (int(size-1) - distance(strongKing, weakKing))
+
+	return score + kingTempo(b, strong, weakKing, p)
+}
+
+// evaluateKBNK drives the lone king to a corner of the color of the bishop
+func evaluateKBNK(b *Board, strong int, m *materialCount, p *EvalParams) int {
+	strongKing, weakKing := kingSquares(b, strong)
+
+	corners := []int8{int8(A8), int8(H1)}
+	for sq := int8(0); sq < boardSize; sq++ {
+		if b.legalSquare(sq) && abs(b.data[sq]) == Bishop && squareColor(sq) == squareColor(int8(A1)) {
+			corners = []int8{int8(A1), int8(H8)}
+		}
+	}
+
+	corner := distance(weakKing, corners[0])
+	if d := distance(weakKing, corners[1]); d < corner {
+		corner = d
+	}
+
+	score := p.KnownWin + m.nonPawnMaterial(strong)
+	score += p.PushToCorner * (int(size-1) - corner)
+	score += p.PushClose * (int(size-1) - distance(strongKing, weakKing))
+
+	return score + kingTempo(b, strong, weakKing, p)
+}
+
+// kingTempo expects the king of the side to move to improve by one step, otherwise the
+// scores of driving the king swing with the side that moved last
+func kingTempo(b *Board, strong int, weakKing int8, p *EvalParams) int {
+	if (b.sideToMove == White) == (strong == 0) {
+		return p.PushClose
+	}
+	if centerDistance(weakKing) > 0 {
+		return -p.PushToEdge
+	}
+	return 0
+}
+
+// evaluateKPK decides king and pawn against king by the rule of the square, the key
+// squares and the rook pawn draw. Other positions are left to the evaluation.
+func evaluateKPK(b *Board, strong int, p *EvalParams) (int, bool) {
+	strongKing, weakKing := kingSquares(b, strong)
+	strongKing, weakKing = relativeSquare(strongKing, strong), relativeSquare(weakKing, strong)
+
+	pawn := int8(0)
+	for sq := int8(0); sq < boardSize; sq++ {
+		if b.legalSquare(sq) && abs(b.data[sq]) == Pawn {
+			pawn = relativeSquare(sq, strong)
+		}
+	}
+
+	r, f := rank(pawn), file(pawn)
+	promotion := square(size-1, f)
+	weakToMove := (b.sideToMove == White) != (strong == 0)
+	win := p.KnownWin + pawnValueEnd + p.PushPawn*int(r)
+
+	// the weak king takes the undefended pawn
+	if weakToMove && distance(weakKing, pawn) == 1 && distance(strongKing, pawn) > 1 {
+		return scoreDraw, true
+	}
+
+	// the rook pawn is drawn once the weak king reaches the corner
+	if (f == 0 || f == size-1) && distance(weakKing, promotion) <= 1 {
+		return scoreDraw, true
+	}
+
+	// rule of the square, the pawn runs unless its own king is in the way
+	pawnMoves := int(size - 1 - r)
+	if r == 1 {
+		pawnMoves--
+	}
+	kingMoves := distance(weakKing, promotion)
+	if weakToMove {
+		kingMoves--
+	}
+	if kingMoves > pawnMoves && (file(strongKing) != f || rank(strongKing) < r) {
+		return win, true
+	}
+
+	// the strong king on a key square wins unless it is a rook pawn
+	if f != 0 && f != size-1 && abs(file(strongKing)-f) <= 1 {
+		keyRank := rank(strongKing) - r
+		if keyRank == 2 || r >= 4 && keyRank == 1 {
+			return win, true
+		}
+	}
+
+	return 0, false
+}
+
+// scaleFactor returns the factor of the endgame score of the strong side for drawish material
+func scaleFactor(b *Board, m *materialCount, strong int, p *EvalParams) int {
+	weak := 1 - strong
+
+	// without pawns a small advantage in pieces does not win
+	if m[strong][Pawn] == 0 && m.nonPawnMaterial(strong)-m.nonPawnMaterial(weak) <= bishopValue {
+		if m.nonPawnMaterial(strong) < rookValue {
+			return scaleDraw
+		}
+		if m.nonPawnMaterial(weak) <= bishopValue {
+			return p.ScaleNoPawnsMinor
+		}
+		return p.ScaleNoPawns
+	}
+
+	if m[0][Bishop] == 1 && m[1][Bishop] == 1 && oppositeBishops(b) {
+		if m.pieces(0) == 1 && m.pieces(1) == 1 {
+			return p.ScaleOppositeBishops
+		}
+		return p.ScaleOppositeBishopsPieces
+	}
+
+	if wrongBishop(b, m, strong) {
+		return scaleDraw
+	}
+
+	return scaleNormal
+}
+
+func squareColor(sq int8) int8 {
+	return (rank(sq) + file(sq)) % 2
+}
+
+// oppositeBishops checks whether the only bishops of the sides are on different colors
+func oppositeBishops(b *Board) bool {
+	colors := [2]int8{}
+	for sq := int8(0); sq < boardSize; sq++ {
+		if !b.legalSquare(sq) || abs(b.data[sq]) != Bishop {
+			continue
+		}
+		if b.data[sq] > 0 {
+			colors[0] = squareColor(sq)
+		} else {
+			colors[1] = squareColor(sq)
+		}
+	}
+	return colors[0] != colors[1]
+}
+
+// wrongBishop checks for rook pawns with a bishop not controlling the promotion square
+// and the weak king in front of them
+func wrongBishop(b *Board, m *materialCount, strong int) bool {
+	if m[strong][Pawn] == 0 || m[strong][Bishop] == 0 || m.pieces(strong) != m[strong][Bishop] || m.pieces(1-strong) > 0 {
+		return false
+	}
+
+	_, weakKing := kingSquares(b, strong)
+	pawnFile, bishopColor := int8(-1), int8(-1)
+
+	for sq := int8(0); sq < boardSize; sq++ {
+		if !b.legalSquare(sq) || b.data[sq] == Empty || (b.data[sq] > 0) != (strong == 0) {
+			continue
+		}
+		switch abs(b.data[sq]) {
+		case Pawn:
+			if f := file(sq); f != 0 && f != size-1 || pawnFile >= 0 && f != pawnFile {
+				return false
+			}
+			pawnFile = file(sq)
+		case Bishop:
+			if bishopColor >= 0 && bishopColor != squareColor(sq) {
+				return false
+			}
+			bishopColor = squareColor(sq)
+		}
+	}
+
+	promotion := square(size-1, pawnFile)
+	if strong == 1 {
+		promotion = square(0, pawnFile)
+	}
+
+	return bishopColor != squareColor(promotion) && distance(weakKing, promotion) <= 1
+}
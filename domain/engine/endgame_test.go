@@ -0,0 +1,111 @@
+package engine
+
+import "testing"
+
+func TestEndgameDeadDraws(t *testing.T) {
+	for _, fen := range []string{
+		"4k3/8/8/8/8/8/8/4K3 w - - 0 1",
+		"4k3/8/8/8/8/8/8/3NK3 w - - 0 1",
+		"4k3/8/8/8/8/8/8/3BK3 b - - 0 1",
+		"4k3/8/8/8/8/8/8/2NNK3 w - - 0 1",
+	} {
+		doTestEvalForFEN(fen, t, scoreDraw)
+	}
+}
+
+func TestEndgameKXKDrivesKingToEdge(t *testing.T) {
+	edge, _ := parseFEN("3k4/8/3K4/8/8/8/8/7R w - - 0 1")
+	center, _ := parseFEN("8/8/3K4/8/3k4/8/8/7R w - - 0 1")
+
+	if Evaluate(edge) <= Evaluate(center) || Evaluate(center) < evalKnownWin {
+		t.Errorf("Expected a known win, better with the king on the edge, but found %d and %d\n",
+			Evaluate(edge), Evaluate(center))
+	}
+}
+
+func TestEndgameKPKRanksBelowPromotion(t *testing.T) {
+	// the best pawn against the worst queen, the king in the center far from the other one
+	pawn, _ := parseFEN("7k/P7/8/8/8/8/8/K7 w - - 0 1")
+	queen, _ := parseFEN("8/8/8/4k3/8/8/8/Q6K b - - 0 1")
+
+	if Evaluate(pawn) < evalKnownWin || Evaluate(pawn) >= -Evaluate(queen) {
+		t.Errorf("Expected the pawn to score below the queen but found %d and %d\n",
+			Evaluate(pawn), -Evaluate(queen))
+	}
+}
+
+func TestEndgameKBNKDrivesKingToBishopCorner(t *testing.T) {
+	// the bishop on the light squares mates in a8 or h1
+	right, _ := parseFEN("k7/8/1K6/8/8/8/8/3B1N2 w - - 0 1")
+	wrong, _ := parseFEN("7k/8/6K1/8/8/8/8/3B1N2 w - - 0 1")
+
+	if Evaluate(right) <= Evaluate(wrong) {
+		t.Errorf("Expected the corner of the bishop to score better but found %d and %d\n",
+			Evaluate(right), Evaluate(wrong))
+	}
+}
+
+func TestEndgameKPK(t *testing.T) {
+	tests := []struct {
+		fen string
+		win bool
+	}{
+		// the king is outside the square of the pawn
+		{"7k/8/8/8/P7/8/8/7K w - - 0 1", true},
+		// the king on a key square
+		{"4k3/8/3K4/8/3P4/8/8/8 b - - 0 1", true},
+		// the rook pawn with the king in the corner
+		{"k7/8/8/8/P7/8/8/7K w - - 0 1", false},
+		// the pawn falls
+		{"8/8/8/8/8/3k4/3P4/7K b - - 0 1", false},
+	}
+
+	for _, test := range tests {
+		b, _ := parseFEN(test.fen)
+		score := Evaluate(b)
+		if b.sideToMove == Black {
+			score = -score
+		}
+
+		if win := score >= evalKnownWin; win != test.win || !test.win && score != scoreDraw {
+			t.Errorf("Expected win %t but found %d for %s\n", test.win, score, test.fen)
+		}
+	}
+}
+
+func TestEndgameScalesDrawishMaterial(t *testing.T) {
+	tests := []struct {
+		name    string
+		drawish string
+		normal  string
+	}{
+		{"opposite bishops", "4k3/5b2/8/3P4/2P5/8/8/2B1K3 w - - 0 1", "4k3/4b3/8/3P4/2P5/8/8/2B1K3 w - - 0 1"},
+		{"wrong bishop", "7k/8/7P/8/8/8/8/3BK3 w - - 0 1", "7k/8/7P/8/8/8/8/2B1K3 w - - 0 1"},
+		{"no pawns", "4k3/8/8/8/8/8/8/2R1K1b1 w - - 0 1", "4k3/8/8/8/8/8/8/2Q1K1b1 w - - 0 1"},
+	}
+
+	for _, test := range tests {
+		drawish, _ := parseFEN(test.drawish)
+		normal, _ := parseFEN(test.normal)
+
+		if Evaluate(drawish) >= Evaluate(normal)/2 {
+			t.Errorf("%s: Expected a drawish score but found %d against %d\n", test.name, Evaluate(drawish), Evaluate(normal))
+		}
+	}
+}
+
+func TestEndgameBreakdownAddsUpToScore(t *testing.T) {
+	b, _ := parseFEN("3k4/8/3K4/8/8/8/8/7R b - - 0 1")
+	terms, score := EvaluateBreakdown(b)
+
+	middle, end := 0, 0
+	for _, term := range terms {
+		middle += term.Middle[0] - term.Middle[1]
+		end += term.End[0] - term.End[1]
+	}
+
+	phase := b.evaluation(defaultProfile()).phase
+	if findTerm(terms, "Endgame") == nil || score != -taper(middle, end, phase) || Evaluate(b) != score {
+		t.Errorf("Expected an endgame term and the terms to add up to %d but found %+v\n", score, terms)
+	}
+}
@@ -8,13 +8,24 @@ const (
 	queenValue  = 1050
 	kingValue   = 40000
 
+	pawnValueEnd   = 120
+	knightValueEnd = 305
+	bishopValueEnd = 335
+	rookValueEnd   = 540
+	queenValueEnd  = 1050
+
 	evalPenaltyDoublePawn    = -8
 	evalBonusCasteling       = 16
 	evalBonusCheck           = 50
 	evalBonusEndgamePawnMove = 50
-	evalEndGameLevel         = 1500
 	evalMateSearchLevel      = 600
-	evalKingSafteyDivisor    = 3100
+
+	// game phase weights of the pieces, all pieces on the board make the midgame
+	evalPhaseKnight = 1
+	evalPhaseBishop = 1
+	evalPhaseRook   = 2
+	evalPhaseQueen  = 4
+	evalPhaseTotal  = 4*evalPhaseKnight + 4*evalPhaseBishop + 4*evalPhaseRook + 2*evalPhaseQueen
 
 	scoreMate = 24000
 	scoreDraw = 0
@@ -32,7 +43,7 @@ var (
 		0, 1, 2, 3, 4, 5, 6, 7, 0, 0, 0, 0, 0, 0, 0, 0,
 	}
 
-	pawnTable = []int{
+	pawnTableMiddle = []int{
 		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
 		5, 10, 10, -20, -20, 10, 10, 5, 0, 0, 0, 0, 0, 0, 0, 0,
 		5, -5, -10, 0, 0, -10, -5, 5, 0, 0, 0, 0, 0, 0, 0, 0,
@@ -43,7 +54,7 @@ var (
 		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
 	}
 
-	knightTable = []int{
+	knightTableMiddle = []int{
 		-50, -40, -30, -30, -30, -30, -40, -50, 0, 0, 0, 0, 0, 0, 0, 0,
 		-40, -20, 0, 5, 5, 0, -20, -40, 0, 0, 0, 0, 0, 0, 0, 0,
 		-30, 0, 10, 15, 15, 10, 0, -30, 0, 0, 0, 0, 0, 0, 0, 0,
@@ -54,7 +65,7 @@ var (
 		-50, -40, -30, -30, -30, -30, -40, -50, 0, 0, 0, 0, 0, 0, 0, 0,
 	}
 
-	bishopTable = []int{
+	bishopTableMiddle = []int{
 		-20, -10, -10, -10, -10, -10, -10, -20, 0, 0, 0, 0, 0, 0, 0, 0,
 		-10, 5, 0, 0, 0, 0, 5, -10, 0, 0, 0, 0, 0, 0, 0, 0,
 		-10, 10, 10, 10, 10, 10, 10, -10, 0, 0, 0, 0, 0, 0, 0, 0,
@@ -65,7 +76,7 @@ var (
 		-20, -10, -10, -10, -10, -10, -10, -20, 0, 0, 0, 0, 0, 0, 0, 0,
 	}
 
-	rookTable = []int{
+	rookTableMiddle = []int{
 		0, 0, 0, 5, 5, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
 		-5, 0, 0, 0, 0, 0, 0, -5, 0, 0, 0, 0, 0, 0, 0, 0,
 		-5, 0, 0, 0, 0, 0, 0, -5, 0, 0, 0, 0, 0, 0, 0, 0,
@@ -76,7 +87,7 @@ var (
 		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
 	}
 
-	queenTable = []int{
+	queenTableMiddle = []int{
 		-20, -10, -10, -5, -5, -10, -10, -20, 0, 0, 0, 0, 0, 0, 0, 0,
 		-10, 0, 0, 0, 0, 5, 0, -10, 0, 0, 0, 0, 0, 0, 0, 0,
 		-10, 0, 5, 5, 5, 5, 5, -10, 0, 0, 0, 0, 0, 0, 0, 0,
@@ -87,6 +98,61 @@ var (
 		-20, -10, -10, -5, -5, -10, -10, -20, 0, 0, 0, 0, 0, 0, 0, 0,
 	}
 
+	pawnTableEnd = []int{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		5, 5, 5, 5, 5, 5, 5, 5, 0, 0, 0, 0, 0, 0, 0, 0,
+		10, 10, 10, 10, 10, 10, 10, 10, 0, 0, 0, 0, 0, 0, 0, 0,
+		20, 20, 20, 20, 20, 20, 20, 20, 0, 0, 0, 0, 0, 0, 0, 0,
+		35, 35, 35, 35, 35, 35, 35, 35, 0, 0, 0, 0, 0, 0, 0, 0,
+		60, 60, 60, 60, 60, 60, 60, 60, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	}
+
+	knightTableEnd = []int{
+		-40, -30, -20, -20, -20, -20, -30, -40, 0, 0, 0, 0, 0, 0, 0, 0,
+		-30, -15, -5, 0, 0, -5, -15, -30, 0, 0, 0, 0, 0, 0, 0, 0,
+		-20, -5, 10, 15, 15, 10, -5, -20, 0, 0, 0, 0, 0, 0, 0, 0,
+		-20, 0, 15, 20, 20, 15, 0, -20, 0, 0, 0, 0, 0, 0, 0, 0,
+		-20, 0, 15, 20, 20, 15, 0, -20, 0, 0, 0, 0, 0, 0, 0, 0,
+		-20, -5, 10, 15, 15, 10, -5, -20, 0, 0, 0, 0, 0, 0, 0, 0,
+		-30, -15, -5, 0, 0, -5, -15, -30, 0, 0, 0, 0, 0, 0, 0, 0,
+		-40, -30, -20, -20, -20, -20, -30, -40, 0, 0, 0, 0, 0, 0, 0, 0,
+	}
+
+	bishopTableEnd = []int{
+		-15, -10, -10, -10, -10, -10, -10, -15, 0, 0, 0, 0, 0, 0, 0, 0,
+		-10, 0, 0, 0, 0, 0, 0, -10, 0, 0, 0, 0, 0, 0, 0, 0,
+		-10, 0, 5, 5, 5, 5, 0, -10, 0, 0, 0, 0, 0, 0, 0, 0,
+		-10, 0, 5, 10, 10, 5, 0, -10, 0, 0, 0, 0, 0, 0, 0, 0,
+		-10, 0, 5, 10, 10, 5, 0, -10, 0, 0, 0, 0, 0, 0, 0, 0,
+		-10, 0, 5, 5, 5, 5, 0, -10, 0, 0, 0, 0, 0, 0, 0, 0,
+		-10, 0, 0, 0, 0, 0, 0, -10, 0, 0, 0, 0, 0, 0, 0, 0,
+		-15, -10, -10, -10, -10, -10, -10, -15, 0, 0, 0, 0, 0, 0, 0, 0,
+	}
+
+	rookTableEnd = []int{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		10, 10, 10, 10, 10, 10, 10, 10, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	}
+
+	queenTableEnd = []int{
+		-20, -10, -10, -5, -5, -10, -10, -20, 0, 0, 0, 0, 0, 0, 0, 0,
+		-10, 0, 5, 5, 5, 5, 0, -10, 0, 0, 0, 0, 0, 0, 0, 0,
+		-10, 5, 10, 10, 10, 10, 5, -10, 0, 0, 0, 0, 0, 0, 0, 0,
+		-5, 5, 10, 15, 15, 10, 5, -5, 0, 0, 0, 0, 0, 0, 0, 0,
+		-5, 5, 10, 15, 15, 10, 5, -5, 0, 0, 0, 0, 0, 0, 0, 0,
+		-10, 5, 10, 10, 10, 10, 5, -10, 0, 0, 0, 0, 0, 0, 0, 0,
+		-10, 0, 5, 5, 5, 5, 0, -10, 0, 0, 0, 0, 0, 0, 0, 0,
+		-20, -10, -10, -5, -5, -10, -10, -20, 0, 0, 0, 0, 0, 0, 0, 0,
+	}
+
 	kingTableMiddle = []int{
 		20, 30, 10, 0, 0, 10, 30, 20, 0, 0, 0, 0, 0, 0, 0, 0,
 		20, 20, 0, 0, 0, 0, 20, 20, 0, 0, 0, 0, 0, 0, 0, 0,
@@ -108,154 +174,170 @@ var (
 		-30, -20, -10, 0, 0, -10, -20, -30, 0, 0, 0, 0, 0, 0, 0, 0,
 		-50, -40, -30, -20, -20, -30, -40, -50, 0, 0, 0, 0, 0, 0, 0, 0,
 	}
+
+	// lookups by uncolored piece
+	pieceValueMiddle = []int{0, pawnValue, knightValue, bishopValue, rookValue, queenValue}
+	piecePhase       = []int{0, 0, evalPhaseKnight, evalPhaseBishop, evalPhaseRook, evalPhaseQueen}
 )
 
+// EvalTerm is the score of one evaluation term for White and Black
+type EvalTerm struct {
+	Name   string
+	Middle [2]int
+	End    [2]int
+}
+
+// evalTerms accumulates the scores of White and Black, keeping every term for a breakdown
+type evalTerms struct {
+	middle, end [2]int
+	breakdown   bool
+	terms       []EvalTerm
+}
+
+func (t *evalTerms) add(name string, side int, middle, end int) {
+	t.middle[side] += middle
+	t.end[side] += end
+
+	if !t.breakdown {
+		return
+	}
+	for i := range t.terms {
+		if t.terms[i].Name == name {
+			t.terms[i].Middle[side] += middle
+			t.terms[i].End[side] += end
+			return
+		}
+	}
+	term := EvalTerm{Name: name}
+	term.Middle[side], term.End[side] = middle, end
+	t.terms = append(t.terms, term)
+}
+
+func (t *evalTerms) addWeight(name string, side int, w EvalWeight) {
+	t.add(name, side, w.Middle, w.End)
+}
+
 // Evaluate the score of a given board
 func Evaluate(b *Board) int {
+	return evaluate(b, nil)
+}
 
-	scoreWhite := 0
-	scoreBlack := 0
+// EvaluateBreakdown evaluates a given board and returns the scores of all terms
+func EvaluateBreakdown(b *Board) ([]EvalTerm, int) {
+	terms := evalTerms{breakdown: true}
+	score := evaluateTerms(b, nil, defaultProfile(), &terms)
+	return terms.terms, score
+}
 
-	materialWhite := 0
-	materialBlack := 0
+// evaluate scores the board with the pawn structures cached in the given table, if any
+func evaluate(b *Board, pawns *pawnHashTable) int {
+	return evaluateTerms(b, pawns, defaultProfile(), &evalTerms{})
+}
+
+func evaluateTerms(b *Board, pawns *pawnHashTable, p *EvalParams, terms *evalTerms) int {
+
+	// indexed by White and Black
+	var attackUnits, attackers [2]int
+	kings := [2]int8{int8(b.whiteKingPosition), int8(b.blackKingPosition)}
+
+	// material and piece squares are kept by the moves of the board
+	e := b.evaluation(p)
+	for side := range e.material {
+		terms.addWeight("Material", side, e.material[side])
+		terms.addWeight("PieceSquares", side, e.pieceSquares[side])
+	}
 
 	for rank := int8(0); rank < size; rank++ {
 		for file := int8(0); file < size; file++ {
 			sq := square(rank, file)
-			switch b.data[sq] {
-			case WhitePawn:
-				materialWhite += pawnValue
-				scoreWhite += evaluatePawn(b, sq)
-			case WhiteKnight:
-				materialWhite += knightValue
-				scoreWhite += evaluateKnight(b, sq)
-			case WhiteBishop:
-				materialWhite += bishopValue
-				scoreWhite += evaluateBishop(b, sq)
-			case WhiteRook:
-				materialWhite += rookValue
-				scoreWhite += evaluateRook(b, sq)
-			case WhiteQueen:
-				materialWhite += queenValue
-				scoreWhite += evaluateQueen(b, sq)
-			case BlackPawn:
-				materialBlack += pawnValue
-				scoreBlack += evaluatePawn(b, sq)
-			case BlackKnight:
-				materialBlack += knightValue
-				scoreBlack += evaluateKnight(b, sq)
-			case BlackBishop:
-				materialBlack += bishopValue
-				scoreBlack += evaluateBishop(b, sq)
-			case BlackRook:
-				materialBlack += rookValue
-				scoreBlack += evaluateRook(b, sq)
-			case BlackQueen:
-				materialBlack += queenValue
-				scoreBlack += evaluateQueen(b, sq)
+			piece := b.data[sq]
+			kind := abs(piece)
+
+			if piece == Empty || kind == Pawn || kind == King {
+				continue
 			}
+
+			side := 0
+			if piece < 0 {
+				side = 1
+			}
+
+			mobility, zone := pieceActivity(b, sq, kings[1-side])
+			middle, end := evaluateMobility(kind, mobility, p)
+			terms.add("Mobility", side, middle, end)
+
+			if zone > 0 {
+				attackUnits[side] += zone * p.KingAttackUnits[kind]
+				attackers[side]++
+			}
+
+			evaluatePiece(b, sq, side, p, terms)
+		}
+	}
+
+	// pawn structure
+	pawnsMiddle, pawnsEnd := evaluatePawns(b, pawns, p)
+	for side := range pawnsMiddle {
+		terms.add("Pawns", side, pawnsMiddle[side], pawnsEnd[side])
+	}
+
+	for side := range e.counts {
+		if e.counts[side][Bishop] >= 2 {
+			terms.addWeight("BishopPair", side, p.BishopPair)
 		}
 	}
 
 	// mate level?
-	if materialWhite <= evalMateSearchLevel || materialBlack <= evalMateSearchLevel {
+	if e.material[0].Middle <= p.MateSearchLevel || e.material[1].Middle <= p.MateSearchLevel {
 		generator := NewGenerator(b)
 
 		if generator.CheckSimple() {
 			// if white is to move, black just made a check move
+			side := 0
 			if b.sideToMove == White {
-				scoreBlack += evalBonusCheck
-			} else {
-				scoreWhite += evalBonusCheck
+				side = 1
 			}
+			terms.addWeight("Check", side, p.Check)
 		}
 	}
 
 	// evaluate kings
-	scoreWhite += evaluateKing(b, int8(b.whiteKingPosition), materialWhite, materialBlack)
-	scoreBlack += evaluateKing(b, int8(b.blackKingPosition), materialWhite, materialBlack)
-
-	// special moves
-
-	// casteling bonus
-
-	scoreWhite += materialWhite
-	scoreBlack += materialBlack
-
-	return int(b.sideToMove) * (scoreWhite - scoreBlack)
-}
-
-func evaluateKing(b *Board, sq int8, materialWhite int, materialBlack int) int {
-	score := 0
-
-	// White
-	if b.data[sq] > 0 {
-		if materialWhite > evalEndGameLevel {
-			score += kingTableMiddle[sq]
-		} else {
-			score += kingTableEnd[sq]
+	for side, sq := range kings {
+		if abs(b.data[sq]) != King {
+			continue
 		}
-		// king saftey
-		score *= materialBlack
-		score /= evalKingSafteyDivisor
-
-	} else {
-		if materialBlack > evalEndGameLevel {
-			score += kingTableMiddle[flipTable[sq]]
-		} else {
-			score += kingTableEnd[flipTable[sq]]
+		// the shelter and the attack on the king matter while the opponent has a queen
+		if e.counts[1-side][Queen] > 0 {
+			terms.add("PawnShelter", side, evaluatePawnShelter(b, sq, p), 0)
+			terms.add("KingSafety", side, kingAttackPenalty(attackUnits[1-side], attackers[1-side], p), 0)
 		}
-		// king saftey
-		score *= materialWhite
-		score /= evalKingSafteyDivisor
+		evaluateCasteling(b, sq, side, p, terms)
 	}
 
-	return score
-}
-
-func evaluatePawn(b *Board, sq int8) int {
+	middle, end := terms.middle[0]-terms.middle[1], terms.end[0]-terms.end[1]
 
-	if b.data[sq] > 0 {
-		score := pawnTable[sq]
-		if sq >= nextRank && b.data[sq-nextRank] == WhitePawn {
-			score += evalPenaltyDoublePawn
-		}
-
-		return score
+	// a known endgame replaces the sum of the other terms
+	if score, known := evaluateEndgame(b, &e.counts, p); known {
+		terms.add("Endgame", 0, score-middle, score-end)
+		return int(b.sideToMove) * score
 	}
 
-	score := pawnTable[flipTable[sq]]
-	if sq+nextRank < boardSize && sq+nextRank >= 0 && b.data[sq+nextRank] == BlackPawn {
-		score += evalPenaltyDoublePawn
-	}
-
-	return score
-}
-
-func evaluateKnight(b *Board, sq int8) int {
-	if b.data[sq] > 0 {
-		return knightTable[sq]
+	// drawish material scales the endgame score of the side ahead
+	strong := 0
+	if end < 0 {
+		strong = 1
 	}
-	return knightTable[flipTable[sq]]
-}
+	end = end * scaleFactor(b, &e.counts, strong, p) / scaleNormal
 
-func evaluateBishop(b *Board, sq int8) int {
-	if b.data[sq] > 0 {
-		return bishopTable[sq]
-	}
-	return bishopTable[flipTable[sq]]
-}
+	score := taper(middle, end, e.phase)
 
-func evaluateRook(b *Board, sq int8) int {
-	if b.data[sq] > 0 {
-		return rookTable[sq]
-	}
-	return rookTable[flipTable[sq]]
+	return int(b.sideToMove) * score
 }
 
-func evaluateQueen(b *Board, sq int8) int {
-	if b.data[sq] > 0 {
-		return queenTable[sq]
+// taper interpolates between midgame and endgame scores by the game phase
+func taper(middle, end, phase int) int {
+	if phase > evalPhaseTotal {
+		phase = evalPhaseTotal
 	}
-	return queenTable[flipTable[sq]]
+	return (middle*phase + end*(evalPhaseTotal-phase)) / evalPhaseTotal
 }
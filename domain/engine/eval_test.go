@@ -7,7 +7,17 @@ func TestEvaluateStartingPosition(t *testing.T) {
 }
 
 func TestEvaluateOnePawnStartingPosition(t *testing.T) {
-	doTestEvalForFEN("8/pppppppp/8/8/8/8/8/8 b - - 0 1", t, 810)
+	// without pieces the pawns are valued as in the endgame, all of them connected and passed
+	doTestEvalForFEN("8/pppppppp/8/8/8/8/8/8 b - - 0 1", t, 1104)
+}
+
+func TestEvaluateMinorTradeHasNoJump(t *testing.T) {
+	before, _ := parseFEN("r1b3k1/p4ppp/2n5/8/4K3/2N5/P4PPP/R1B5 w - - 0 1")
+	after, _ := parseFEN("r1b3k1/p4ppp/8/8/4K3/8/P4PPP/R1B5 w - - 0 1")
+
+	if diff := Evaluate(after) - Evaluate(before); diff > 15 || diff < -15 {
+		t.Errorf("Expected trading knights to change the score by at most 15 but it changed by %d\n", diff)
+	}
 }
 
 func doTestEvalForFEN(fen string, t *testing.T, e int) {
@@ -20,3 +30,46 @@ func doTestEvalForFEN(fen string, t *testing.T, e int) {
 	}
 
 }
+
+// evalTestDepth searches the candidates deep enough to see the replies, a fixed depth keeps
+// the choice independent of the speed of the machine
+const evalTestDepth = 4
+
+func TestEvaluationChoosesPositionalMove(t *testing.T) {
+	tests := []struct {
+		name       string
+		fen        string
+		candidates []string
+		expected   string
+	}{
+		// the pawn shield keeps the pawns in front of the castled king
+		{"shield", "r1bq1rk1/pppp1pbp/2n2np1/4p3/4P3/2N2N2/PPPPBPPP/R1BQ1RK1 w - - 0 1", []string{"h2h4", "g2g4", "b2b3"}, "b2b3"},
+		// the queen joins the knight attacking the king zone
+		{"attack", "r1b2rk1/pppp1ppp/2n2q2/4p1N1/2B1P3/8/PPPP1PPP/RNBQ1RK1 w - - 0 1", []string{"d1e2", "d1f3", "d1h5"}, "d1h5"},
+		// the rook is more mobile on the open file
+		{"mobility", "r4rk1/pp3ppp/2p5/8/8/2P5/PP3PPP/R4RK1 w - - 0 1", []string{"h2h3", "a2a3", "f1e1"}, "f1e1"},
+	}
+
+	searchVerbose = false
+
+	for _, test := range tests {
+		b := NewBoard(test.fen)
+		options := SearchOptions{Depth: evalTestDepth}
+
+		for _, candidate := range test.candidates {
+			move, err := b.LegalMove(candidate)
+			if err != nil {
+				t.Fatalf("%s: %s\n", test.name, err)
+			}
+			options.SearchMoves = append(options.SearchMoves, move)
+		}
+
+		best := ""
+		if lines := SearchLines(b, options); len(lines) > 0 {
+			best = lines[0].Move.String()
+		}
+		if best != test.expected {
+			t.Errorf("%s: Expected %s to be chosen but found %s\n", test.name, test.expected, best)
+		}
+	}
+}
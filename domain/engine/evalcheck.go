@@ -0,0 +1,223 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// evalCheckSeeds are the positions the corpus of the evaluation check grows from
+var evalCheckSeeds = []string{
+	defaultFEN,
+	position2FEN,
+	"r1bq1rk1/pppp1pbp/2n2np1/4p3/2B1P3/2N2N2/PPPP1PPP/R1BQ1RK1 b - - 0 1",
+	"r2q1rk1/pp2bppp/2n1pn2/3p4/2PP4/2N1PN2/PP3PPP/R2QKB1R w KQ - 0 9",
+	"rnbqkb1r/pp3ppp/4pn2/2pp4/3P4/2P1PN2/PP1N1PPP/R1BQKB1R b KQkq - 0 5",
+	"r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+	"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+	"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+	"4k3/pp3ppp/8/3p4/3P4/8/PP3PPP/4K3 w - - 0 1",
+	"6k1/5pp1/7p/8/8/6P1/5PKP/3R4 w - - 0 1",
+	"8/8/4k3/8/2B5/8/3PK3/8 w - - 0 1",
+	"8/5k2/8/3n4/8/8/2B1K3/8 b - - 0 1",
+	"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1",
+	"2r3k1/p4ppp/8/8/8/8/P4PPP/2R3K1 b - - 0 1",
+}
+
+// EvalCheckFailure is a position that violates a property of the evaluation
+type EvalCheckFailure struct {
+	FEN      string
+	Check    string
+	Expected int
+	Found    int
+}
+
+func (f EvalCheckFailure) String() string {
+	return fmt.Sprintf("%s: expected %d but found %d in %s", f.Check, f.Expected, f.Found, f.FEN)
+}
+
+// EvalCheckCorpus returns the seed positions and all positions one move after them
+func EvalCheckCorpus() []string {
+	fens := []string{}
+	seen := map[string]bool{}
+
+	for _, seed := range evalCheckSeeds {
+		b := NewBoard(seed)
+		for _, fen := range append([]string{seed}, childFENs(b)...) {
+			if !seen[fen] {
+				seen[fen] = true
+				fens = append(fens, fen)
+			}
+		}
+	}
+	return fens
+}
+
+// LoadEvalCheckCorpus reads one FEN or EPD position per line, ignoring comments
+func LoadEvalCheckCorpus(path string) ([]string, error) {
+	input, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	fens := []string{}
+	scanner := bufio.NewScanner(input)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		// the first four fields of an EPD are the position, a FEN adds the two clocks
+		fields := strings.Fields(text)
+		if len(fields) > 4 {
+			if len(fields) >= 6 && isClock(fields[4]) && isClock(fields[5]) {
+				fields = fields[:6]
+			} else {
+				fields = fields[:4]
+			}
+		}
+		fen := strings.Join(fields, " ")
+		if _, err := parseFEN(fen); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		fens = append(fens, fen)
+	}
+
+	return fens, scanner.Err()
+}
+
+// isClock checks whether a field of a FEN is a move counter
+func isClock(field string) bool {
+	value, err := strconv.Atoi(field)
+	return err == nil && value >= 0
+}
+
+// CheckEvaluation verifies over the positions that the evaluation with the parameters
+//   - scores the color flipped position alike,
+//   - does not depend on the moves that led to the position and
+//   - changes its sign with the side to move, unless the side to move matters.
+func CheckEvaluation(fens []string, p *EvalParams) []EvalCheckFailure {
+	failures := []EvalCheckFailure{}
+	fail := func(fen, check string, expected, found int) {
+		failures = append(failures, EvalCheckFailure{FEN: fen, Check: check, Expected: expected, Found: found})
+	}
+	eval := func(b *Board) int {
+		return evaluateTerms(b, nil, p, &evalTerms{})
+	}
+
+	for _, fen := range fens {
+		b := NewBoard(fen)
+		score := eval(b)
+
+		if flipped := eval(flipColors(b)); flipped != score {
+			fail(fen, "symmetry", score, flipped)
+		}
+
+		for _, move := range NewGenerator(b).GenerateMoves() {
+			b.MakeMove(move)
+			if played, fresh := eval(b), eval(NewBoard(generateFEN(b))); played != fresh {
+				fail(fen, "history after "+move.UciString(), fresh, played)
+			}
+			b.UndoMove()
+		}
+		if undone := eval(b); undone != score {
+			fail(fen, "history after undo", score, undone)
+		}
+
+		if other, ok := otherSideToMove(b); ok {
+			if found := eval(other); found != -score {
+				fail(fen, "side to move", -score, found)
+			}
+		}
+	}
+
+	return failures
+}
+
+// flipColors mirrors the board with flipTable and swaps the colors of the pieces and the side to move
+func flipColors(b *Board) *Board {
+	flipped := Board{
+		sideToMove:    opponent(b.sideToMove),
+		whiteCastle:   b.blackCastle,
+		blackCastle:   b.whiteCastle,
+		enPassant:     Invalid,
+		halfMoveClock: b.halfMoveClock,
+		fullMoves:     b.fullMoves,
+	}
+
+	for sq := int8(0); sq < boardSize; sq++ {
+		if b.legalSquare(sq) {
+			flipped.data[flipTable[sq]] = -b.data[sq]
+		}
+	}
+	if b.enPassant != Invalid {
+		flipped.enPassant = Square(flipTable[b.enPassant])
+	}
+
+	return NewBoard(generateFEN(&flipped))
+}
+
+// otherSideToMove returns the position with the other side to move, unless a king is in
+// check or the position is a known endgame where the side to move decides
+func otherSideToMove(b *Board) (*Board, bool) {
+	other := *b
+	other.sideToMove = opponent(b.sideToMove)
+	other.enPassant = Invalid
+	other.history = nil
+	other = *NewBoard(generateFEN(&other))
+
+	if NewGenerator(b).CheckSimple() || NewGenerator(&other).CheckSimple() {
+		return nil, false
+	}
+	if _, known := evaluateEndgame(b, &b.evaluation(defaultProfile()).counts, defaultProfile()); known {
+		return nil, false
+	}
+
+	return &other, true
+}
+
+// childFENs returns the positions after every legal move
+func childFENs(b *Board) []string {
+	fens := []string{}
+	for _, move := range NewGenerator(b).GenerateMoves() {
+		b.MakeMove(move)
+		fens = append(fens, generateFEN(b))
+		b.UndoMove()
+	}
+	return fens
+}
+
+// runEvalCheck runs "evalcheck [<params> [<positions>]]" and prints the violations
+func runEvalCheck(args []string) error {
+	p := defaultProfile()
+	if len(args) > 0 {
+		params, err := LoadEvalParams(args[0])
+		if err != nil {
+			return err
+		}
+		p = &params
+	}
+
+	fens := EvalCheckCorpus()
+	if len(args) > 1 {
+		corpus, err := LoadEvalCheckCorpus(args[1])
+		if err != nil {
+			return err
+		}
+		fens = corpus
+	}
+
+	failures := CheckEvaluation(fens, p)
+	for _, failure := range failures {
+		fmt.Println(failure)
+	}
+	fmt.Printf("checked %d positions, %d failures\n", len(fens), len(failures))
+	return nil
+}
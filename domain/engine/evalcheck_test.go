@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEvaluationIsConsistent(t *testing.T) {
+	fens := EvalCheckCorpus()
+	if len(fens) < 300 {
+		t.Fatalf("Expected a large corpus but found %d positions\n", len(fens))
+	}
+
+	for _, failure := range CheckEvaluation(fens, defaultProfile()) {
+		t.Error(failure)
+	}
+}
+
+func TestFlipColors(t *testing.T) {
+	b := NewBoard("r3k2r/8/8/3pP3/8/8/8/R3K1R1 w Qkq d6 0 1")
+
+	if fen := generateFEN(flipColors(b)); fen != "r3k1r1/8/8/8/3Pp3/8/8/R3K2R b KQq d3 0 1" {
+		t.Errorf("Expected the colors to be flipped but found %s\n", fen)
+	}
+}
+
+func TestOtherSideToMoveSkipsChecks(t *testing.T) {
+	if _, ok := otherSideToMove(NewBoard("4k3/pppp4/8/8/8/8/4r3/PPPPK3 w - - 0 1")); ok {
+		t.Error("Expected a king in check to keep its side to move")
+	}
+	if other, ok := otherSideToMove(NewBoard(defaultFEN)); !ok || other.sideToMove != Black {
+		t.Error("Expected the start position with black to move")
+	}
+}
+
+func TestLoadEvalCheckCorpus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corpus.epd")
+	content := "# positions\n" + defaultFEN + "\nr3k2r/8/8/8/8/8/8/R3K2R w KQkq - bm O-O; id \"castle\";\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fens, err := LoadEvalCheckCorpus(path)
+	if err != nil || len(fens) != 2 || fens[1] != "r3k2r/8/8/8/8/8/8/R3K2R w KQkq -" {
+		t.Errorf("Expected two positions but found %v (%v)\n", fens, err)
+	}
+}
+
+func TestLoadEvalCheckCorpusCutsOpcodes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corpus.epd")
+	content := "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - bm Bb5;\n" +
+		"r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3 bm Bb5; id \"clocks\";\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fens, err := LoadEvalCheckCorpus(path)
+	if err != nil || len(fens) != 2 {
+		t.Fatalf("Expected two positions but found %v (%v)\n", fens, err)
+	}
+	if fens[0] != "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq -" {
+		t.Errorf("Expected the opcode to be cut but found %s\n", fens[0])
+	}
+	if fens[1] != "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3" {
+		t.Errorf("Expected the clocks to be kept but found %s\n", fens[1])
+	}
+}
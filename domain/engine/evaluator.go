@@ -0,0 +1,25 @@
+package engine
+
+// Evaluator scores positions for the search
+type Evaluator interface {
+	// Evaluate scores the board for the side to move
+	Evaluate(b *Board) int
+}
+
+// handcraftedEvaluator is the default evaluation by named terms with the pawn structures
+// cached for a single search
+type handcraftedEvaluator struct {
+	pawns  *pawnHashTable
+	params *EvalParams
+}
+
+func newHandcraftedEvaluator(params *EvalParams) *handcraftedEvaluator {
+	if params == nil {
+		params = defaultProfile()
+	}
+	return &handcraftedEvaluator{pawns: newPawnHashTable(), params: params}
+}
+
+func (e *handcraftedEvaluator) Evaluate(b *Board) int {
+	return evaluateTerms(b, e.pawns, e.params, &evalTerms{})
+}
@@ -0,0 +1,143 @@
+package engine
+
+import "fmt"
+
+// IllegalReason is the machine readable reason why a move is illegal
+type IllegalReason string
+
+const (
+	IllegalNotation             IllegalReason = "invalid_notation"
+	IllegalNoPiece              IllegalReason = "no_piece"
+	IllegalWrongSide            IllegalReason = "wrong_side"
+	IllegalOwnPiece             IllegalReason = "own_piece"
+	IllegalMovement             IllegalReason = "invalid_movement"
+	IllegalPromotion            IllegalReason = "invalid_promotion"
+	IllegalPathBlocked          IllegalReason = "path_blocked"
+	IllegalKingInCheck          IllegalReason = "king_in_check"
+	IllegalPinned               IllegalReason = "pinned"
+	IllegalCastlingRights       IllegalReason = "castling_rights"
+	IllegalCastlingInCheck      IllegalReason = "castling_in_check"
+	IllegalCastlingThroughCheck IllegalReason = "castling_through_check"
+)
+
+var pieceNames = []string{"", "pawn", "knight", "bishop", "rook", "queen", "king"}
+
+// IllegalMoveError explains why a move is illegal, the message is meant for users
+type IllegalMoveError struct {
+	Move    string
+	Reason  IllegalReason
+	Message string
+}
+
+func (e *IllegalMoveError) Error() string {
+	return fmt.Sprintf("illegal move %s: %s", e.Move, e.Message)
+}
+
+// ExplainMove returns why the piece on a square may not move to another square, or nil
+// if it may
+func (b *Board) ExplainMove(from, to Square) *IllegalMoveError {
+	if !b.legalSquare(int8(from)) || !b.legalSquare(int8(to)) {
+		return &IllegalMoveError{Reason: IllegalNotation, Message: "the squares are not on the board"}
+	}
+
+	m := b.completeMove(from, to, Queen)
+	illegal := func(reason IllegalReason, format string, args ...interface{}) *IllegalMoveError {
+		return &IllegalMoveError{Move: m.UciString(), Reason: reason, Message: fmt.Sprintf(format, args...)}
+	}
+	piece := func(sq Square) string {
+		return fmt.Sprintf("the %s on %s", pieceNames[abs(b.data[sq])], SquareMap[sq])
+	}
+
+	switch {
+	case m.MovedPiece == Empty:
+		return illegal(IllegalNoPiece, "there is no piece on %s", SquareMap[from])
+	case m.MovedPiece*b.sideToMove < 0:
+		return illegal(IllegalWrongSide, "%s is %s, but %s is to move", piece(from), colorName(-b.sideToMove), colorName(b.sideToMove))
+	case from == to:
+		return illegal(IllegalMovement, "%s must leave its square", piece(from))
+	case m.Special == moveCastelingShort || m.Special == moveCastelingLong:
+		return b.explainCastle(m, illegal)
+	case m.Content*m.MovedPiece > 0:
+		return illegal(IllegalOwnPiece, "%s cannot capture %s of its own color", piece(from), piece(to))
+	}
+
+	if !b.reaches(m) {
+		if b.reachesOnEmptyBoard(m) {
+			return illegal(IllegalPathBlocked, "%s is blocked on its way to %s", piece(from), SquareMap[to])
+		}
+		return illegal(IllegalMovement, "%s cannot move to %s", piece(from), SquareMap[to])
+	}
+
+	if b.IsLegal(m) {
+		return nil
+	}
+
+	us, them := sideIndex(b.sideToMove), sideIndex(opponent(b.sideToMove))
+	king, _ := b.bitboards.king(us)
+	if b.bitboards.pinned(king, us, them)&bit(int8(from)) != 0 && lines[king][to64(int8(from))]&bit(int8(to)) == 0 {
+		return illegal(IllegalPinned, "%s is pinned to its king", piece(from))
+	}
+	if abs(m.MovedPiece) == King {
+		return illegal(IllegalKingInCheck, "the king would be in check on %s", SquareMap[to])
+	}
+	if b.InCheck() {
+		return illegal(IllegalKingInCheck, "the move does not get the king out of check")
+	}
+	return illegal(IllegalKingInCheck, "the move would leave the king in check")
+}
+
+// explainCastle returns why the king may not castle
+func (b *Board) explainCastle(m Move, illegal func(IllegalReason, string, ...interface{}) *IllegalMoveError) *IllegalMoveError {
+	rights, right, side, start := b.whiteCastle, castleShort, "short", whiteKingStartSquare
+	if b.sideToMove == Black {
+		rights, start = b.blackCastle, blackKingStartSquare
+	}
+	if m.From != start {
+		return illegal(IllegalMovement, "the king cannot move to %s", SquareMap[m.To])
+	}
+
+	// the rook stands in the corner behind the target of the king
+	rook := m.To + Square(nextFile)
+	if m.Special == moveCastelingLong {
+		right, side, rook = castleLong, "long", m.To-2*Square(nextFile)
+	}
+
+	switch {
+	case rights&right == 0 || b.data[rook] != m.MovedPiece/King*Rook:
+		return illegal(IllegalCastlingRights, "%s has lost the right to castle %s", colorName(b.sideToMove), side)
+	case between[to64(int8(m.From))][to64(int8(rook))]&b.bitboards.occupied() != 0:
+		return illegal(IllegalPathBlocked, "the squares between king and rook are not empty")
+	case b.InCheck():
+		return illegal(IllegalCastlingInCheck, "the king cannot castle out of check")
+	case !b.IsLegal(m):
+		return illegal(IllegalCastlingThroughCheck, "the king cannot castle through or into check")
+	}
+	return nil
+}
+
+// reachesOnEmptyBoard checks whether the moved piece would get to the square without
+// the pieces between
+func (b *Board) reachesOnEmptyBoard(m Move) bool {
+	from, to := int8(m.From), int8(m.To)
+
+	switch kind := abs(m.MovedPiece); kind {
+	case Pawn:
+		forward := m.MovedPiece * nextRank
+		start := whitePawnStartPos
+		if b.sideToMove == Black {
+			start = blackPawnStartPos
+		}
+		return to == from+forward || to == from+2*forward && rank(from) == start
+	case Knight, King:
+		return false
+	default:
+		return pieceAttacks(kind, to64(from), 0)&bit(to) != 0
+	}
+}
+
+func colorName(color int8) string {
+	if color == White {
+		return "white"
+	}
+	return "black"
+}
@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExplainMove(t *testing.T) {
+	tests := []struct {
+		fen      string
+		move     string
+		expected IllegalReason
+	}{
+		{defaultFEN, "e3e4", IllegalNoPiece},
+		{defaultFEN, "e7e5", IllegalWrongSide},
+		{defaultFEN, "d1d2", IllegalOwnPiece},
+		{defaultFEN, "b1b3", IllegalMovement},
+		{defaultFEN, "e2d3", IllegalMovement},
+		{defaultFEN, "a1a3", IllegalPathBlocked},
+		{defaultFEN, "e1g1", IllegalPathBlocked},
+		{"4k3/8/8/8/8/4n3/4P3/4K3 w - - 0 1", "e2e4", IllegalPathBlocked},
+		{"4k3/4r3/8/8/8/8/3P4/4K3 w - - 0 1", "d2d3", IllegalKingInCheck},
+		{"4k3/8/8/8/8/8/5r2/4K3 w - - 0 1", "e1e2", IllegalKingInCheck},
+		{"4k3/4r3/8/8/8/8/4B3/4K3 w - - 0 1", "e2d3", IllegalPinned},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w Qkq - 0 1", "e1g1", IllegalCastlingRights},
+		{"4k3/4r3/8/8/8/8/8/R3K2R w KQ - 0 1", "e1g1", IllegalCastlingInCheck},
+		{"r3k2r/8/8/8/8/8/5r2/R3K2R w KQkq - 0 1", "e1g1", IllegalCastlingThroughCheck},
+		{"8/8/8/K2pP2r/8/8/8/7k w - d6 0 1", "e5d6", IllegalKingInCheck},
+		{defaultFEN, "d1d1", IllegalMovement},
+		{defaultFEN, "e2e4q", IllegalPromotion},
+		{defaultFEN, "g1f3n", IllegalPromotion},
+		{defaultFEN, "e2e5q", IllegalMovement},
+	}
+
+	for _, test := range tests {
+		_, err := NewBoard(test.fen).LegalMove(test.move)
+
+		var illegal *IllegalMoveError
+		if !errors.As(err, &illegal) {
+			t.Errorf("Expected %s to be illegal in %s\n", test.move, test.fen)
+			continue
+		}
+		if illegal.Reason != test.expected || illegal.Move != test.move || illegal.Message == "" {
+			t.Errorf("Expected %s for %s in %s but found %s: %s\n", test.expected, test.move, test.fen, illegal.Reason, illegal.Message)
+		}
+	}
+}
+
+func TestExplainLegalMove(t *testing.T) {
+	b := NewBoard("4k3/4r3/8/8/8/8/4B3/4K3 w - - 0 1")
+
+	// the pinned bishop may not move at all, but the king may
+	if explanation := b.ExplainMove(E1, D1); explanation != nil {
+		t.Errorf("Expected no explanation for a legal move but found %s\n", explanation)
+	}
+	if _, err := b.LegalMove("e9e4"); err == nil || err.(*IllegalMoveError).Reason != IllegalNotation {
+		t.Errorf("Expected an invalid notation but found %v\n", err)
+	}
+}
+
+func TestExplainMoveMatchesIsLegal(t *testing.T) {
+	for _, fen := range []string{position2FEN, "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1"} {
+		b := NewBoard(fen)
+		for from := int8(0); from < boardSize; from++ {
+			for to := int8(0); to < boardSize; to++ {
+				if !b.legalSquare(from) || !b.legalSquare(to) {
+					continue
+				}
+				legal := b.IsLegal(b.completeMove(Square(from), Square(to), Queen))
+				if explanation := b.ExplainMove(Square(from), Square(to)); (explanation == nil) != legal {
+					t.Errorf("Expected legal %v for %s%s but found %v\n", legal, SquareMap[Square(from)], SquareMap[Square(to)], explanation)
+				}
+			}
+		}
+	}
+}
@@ -49,28 +49,7 @@ func generateFEN(board *Board) string {
 	fen += " "
 
 	// casteling
-
-	if board.whiteCastle&castleShort != 0 {
-		fen += "K"
-	}
-
-	if board.whiteCastle&castleLong != 0 {
-		fen += "Q"
-	}
-
-	if board.blackCastle&castleShort != 0 {
-		fen += "k"
-	}
-
-	if board.blackCastle&castleLong != 0 {
-		fen += "q"
-	}
-
-	if (board.whiteCastle<<2 | board.blackCastle) == 0 {
-		fen += "-"
-	}
-
-	fen += " "
+	fen += board.CastlingRights() + " "
 
 	// en passant square
 
@@ -206,5 +185,8 @@ func parseFEN(fen string) (*Board, error) {
 		board.fullMoves = fullMoves
 	}
 
+	board.bitboards = newBitboards(&board)
+	board.eval = newBoardEval(&board, defaultProfile())
+
 	return &board, nil
 }
@@ -2,24 +2,316 @@ package engine
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Game represents a gochess game
 type Game struct {
-	Board *Board
+	Board         *Board
+	options       SearchOptions
+	skillLevel    int
+	limitStrength bool
+	elo           int
+	search        *SearchJob
+	searchDone    chan struct{}
 }
 
+const (
+	optionMultiPVMax          = 500
+	optionMoveOverheadDefault = 10
+	optionMoveOverheadMax     = 5000
+	optionContemptMax         = 100
+)
+
 // NewGame creates a new gochess game and returns a reference
 func NewGame() *Game {
 	g := new(Game)
 	g.Board = NewBoard(defaultFEN)
+	g.options = SearchOptions{MultiPV: 1, MoveOverhead: optionMoveOverheadDefault * time.Millisecond}
+	g.skillLevel = SkillLevelMax
+	g.elo = skillEloMax
 
 	return g
 }
 
+// parseOption splits a "setoption name <id> [value <x>]" command into its name and value
+func parseOption(words []string) (string, string, error) {
+	if len(words) < 3 || words[1] != "name" {
+		return "", "", errors.New("invalid setoption command")
+	}
+
+	for i := 2; i < len(words); i++ {
+		if words[i] == "value" {
+			return strings.Join(words[2:i], " "), strings.Join(words[i+1:], " "), nil
+		}
+	}
+
+	return strings.Join(words[2:], " "), "", nil
+}
+
+// setOption applies a single UCI option to the game
+func (g *Game) setOption(name, value string) error {
+	switch strings.ToLower(name) {
+	case "multipv":
+		multiPV, err := strconv.Atoi(value)
+		if err != nil || multiPV < 1 || multiPV > optionMultiPVMax {
+			return fmt.Errorf("invalid MultiPV value: %s", value)
+		}
+		g.options.MultiPV = multiPV
+	case "skill level":
+		level, err := strconv.Atoi(value)
+		if err != nil || level < 0 || level > SkillLevelMax {
+			return fmt.Errorf("invalid Skill Level value: %s", value)
+		}
+		g.skillLevel = level
+	case "uci_limitstrength":
+		limit, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid UCI_LimitStrength value: %s", value)
+		}
+		g.limitStrength = limit
+	case "uci_elo":
+		elo, err := strconv.Atoi(value)
+		if err != nil || elo < skillEloMin || elo > skillEloMax {
+			return fmt.Errorf("invalid UCI_Elo value: %s", value)
+		}
+		g.elo = elo
+	case "contempt":
+		contempt, err := strconv.Atoi(value)
+		if err != nil || contempt < -optionContemptMax || contempt > optionContemptMax {
+			return fmt.Errorf("invalid Contempt value: %s", value)
+		}
+		g.options.Contempt = contempt
+	case "ponder":
+		// pondering is controlled by "go ponder", the option only announces support
+	case "move overhead":
+		overhead, err := strconv.Atoi(value)
+		if err != nil || overhead < 0 || overhead > optionMoveOverheadMax {
+			return fmt.Errorf("invalid Move Overhead value: %s", value)
+		}
+		g.options.MoveOverhead = time.Duration(overhead) * time.Millisecond
+	case "evalfile":
+		// an empty file returns to the handcrafted evaluation
+		if value == "" || value == "<empty>" {
+			g.options.Network = nil
+			return nil
+		}
+		network, err := LoadNetwork(value)
+		if err != nil {
+			return fmt.Errorf("invalid EvalFile value: %s", err)
+		}
+		g.options.Network = network
+	default:
+		return fmt.Errorf("unknown option: %s", name)
+	}
+
+	return nil
+}
+
+// skill returns the weakening of the play configured by the options, nil for full strength
+func (g *Game) skill() *Skill {
+	level := g.skillLevel
+	if g.limitStrength {
+		if eloLevel := skillLevelFromElo(g.elo); eloLevel < level {
+			level = eloLevel
+		}
+	}
+
+	if level >= SkillLevelMax {
+		return nil
+	}
+	return &Skill{Level: level}
+}
+
+var goKeywords = map[string]bool{
+	"searchmoves": true, "ponder": true, "wtime": true, "btime": true, "winc": true, "binc": true,
+	"movestogo": true, "depth": true, "nodes": true, "mate": true, "movetime": true, "infinite": true,
+}
+
+// parseGo reads the parameters of a "go" command into the options of a single search
+func parseGo(b *Board, words []string, options SearchOptions) (SearchOptions, error) {
+	options.SearchMoves = nil
+	options.Ponder = false
+	options.Infinite = false
+	options.Mate = 0
+	options.Depth = 0
+	options.Nodes = 0
+	options.Clock = TimeControl{}
+
+	for i := 1; i < len(words); i++ {
+		keyword := words[i]
+
+		switch keyword {
+		case "searchmoves":
+			for ; i+1 < len(words) && !goKeywords[words[i+1]]; i++ {
+				move, err := b.LegalMove(words[i+1])
+				if err != nil {
+					return options, err
+				}
+				options.SearchMoves = append(options.SearchMoves, move)
+			}
+			continue
+		case "ponder":
+			options.Ponder = true
+			continue
+		case "infinite":
+			options.Infinite = true
+			continue
+		}
+
+		// all remaining parameters take a number
+		if !goKeywords[keyword] {
+			continue
+		}
+		if i+1 >= len(words) {
+			return options, fmt.Errorf("missing value for %s", keyword)
+		}
+		i++
+		value, err := strconv.Atoi(words[i])
+		if err != nil || value < 0 {
+			return options, fmt.Errorf("invalid %s value: %s", keyword, words[i])
+		}
+		ms := time.Duration(value) * time.Millisecond
+
+		switch keyword {
+		case "mate":
+			if value < 1 {
+				return options, fmt.Errorf("invalid mate value: %s", words[i])
+			}
+			options.Mate = value
+		case "depth":
+			if value < 1 {
+				return options, fmt.Errorf("invalid depth value: %s", words[i])
+			}
+			options.Depth = value
+		case "nodes":
+			if value < 1 {
+				return options, fmt.Errorf("invalid nodes value: %s", words[i])
+			}
+			options.Nodes = int64(value)
+		case "wtime":
+			options.Clock.WhiteTime = ms
+		case "btime":
+			options.Clock.BlackTime = ms
+		case "winc":
+			options.Clock.WhiteInc = ms
+		case "binc":
+			options.Clock.BlackInc = ms
+		case "movestogo":
+			options.Clock.MovesToGo = value
+		case "movetime":
+			options.Clock.MoveTime = ms
+		}
+	}
+
+	return options, nil
+}
+
+// startSearch runs a search in the background and reports its best move when it is done
+func (g *Game) startSearch(options SearchOptions) {
+	g.search = StartSearch(g.Board, options)
+	g.searchDone = make(chan struct{})
+
+	go func(search *SearchJob, done chan struct{}) {
+		lines := search.Wait()
+		printSearchLines(lines)
+
+		if len(lines) > 0 {
+			best := lines[0]
+			if len(best.Pv) > 1 {
+				fmt.Printf("bestmove %s ponder %s\n", best.Move.UciString(), best.Pv[1].UciString())
+			} else {
+				fmt.Printf("bestmove %s\n", best.Move.UciString())
+			}
+			g.Board.MakeMove(best.Move)
+		} else {
+			fmt.Println("bestmove (none)")
+		}
+
+		close(done)
+	}(g.search, g.searchDone)
+}
+
+// stopSearch stops a running search and waits for its best move
+func (g *Game) stopSearch() {
+	if g.search == nil {
+		return
+	}
+	g.search.Stop()
+	g.waitSearch()
+}
+
+// finishSearch lets a running search finish, a ponder search would never finish on its own
+func (g *Game) finishSearch() {
+	if g.search != nil && g.search.Pondering() {
+		g.stopSearch()
+	}
+	g.waitSearch()
+}
+
+// waitSearch blocks until a running search has reported its best move
+func (g *Game) waitSearch() {
+	if g.search == nil {
+		return
+	}
+	<-g.searchDone
+	g.search = nil
+}
+
+// runTune runs "tune <positions> [<output> [<iterations>]]" and writes the tuned parameters
+func runTune(args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing positions file")
+	}
+	output, iterations := tuneDefaultOutput, tuneMaxIterations
+	if len(args) > 1 {
+		output = args[1]
+	}
+	if len(args) > 2 {
+		value, err := strconv.Atoi(args[2])
+		if err != nil || value < 1 || value > tuneMaxIterations {
+			return fmt.Errorf("invalid iterations value: %s", args[2])
+		}
+		iterations = value
+	}
+
+	positions, err := LoadTuningPositions(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("tuning %d positions\n", len(positions))
+
+	params, tuned, err := Tune(positions, *defaultProfile(), iterations, func(iteration int, err float64) {
+		fmt.Printf("iteration %d error %.6f\n", iteration, err)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := WriteEvalParams(output, params); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s with error %.6f\n", output, tuned)
+	return nil
+}
+
+// printSearchLines prints the ranked lines of a search as UCI info output
+func printSearchLines(lines []SearchLine) {
+	for i, line := range lines {
+		pv := make([]string, len(line.Pv))
+		for j, move := range line.Pv {
+			pv[j] = move.UciString()
+		}
+		fmt.Printf("info depth %d multipv %d score %s pv %s\n",
+			line.Depth, i+1, formatUciScore(line.Score), strings.Join(pv, " "))
+	}
+}
+
 // Run a given game
 func (g *Game) Run() {
 
@@ -28,27 +320,43 @@ func (g *Game) Run() {
 	for scanner.Scan() {
 		in := scanner.Text()
 
+		// only these commands are handled while a search is running
+		switch in {
+		case "stop", "quit", "q":
+			g.stopSearch()
+		case "ponderhit":
+			if g.search != nil {
+				g.search.PonderHit()
+			}
+			continue
+		case "isready":
+		default:
+			g.finishSearch()
+		}
+
 		if in == "quit" || in == "q" {
 			break
 
 		} else if in == "uci" {
 			fmt.Println("id name gopher")
 			fmt.Println("id author loganwalker")
+			fmt.Println("option name Ponder type check default false")
+			fmt.Printf("option name MultiPV type spin default 1 min 1 max %d\n", optionMultiPVMax)
+			fmt.Printf("option name Move Overhead type spin default %d min 0 max %d\n",
+				optionMoveOverheadDefault, optionMoveOverheadMax)
+			fmt.Printf("option name Skill Level type spin default %d min 0 max %d\n", SkillLevelMax, SkillLevelMax)
+			fmt.Println("option name UCI_LimitStrength type check default false")
+			fmt.Printf("option name Contempt type spin default 0 min %d max %d\n", -optionContemptMax, optionContemptMax)
+			fmt.Printf("option name UCI_Elo type spin default %d min %d max %d\n", skillEloMax, skillEloMin, skillEloMax)
+			fmt.Println("option name EvalFile type string default <empty>")
 			fmt.Println("uciok")
 		}else if strings.HasPrefix(in, "setoption"){
-			words := strings.Fields(in)
-			if len(words) > 1 {
-				if words[1] == "name" {
-					option := strings.Join(words[2:3], " ")
-					if option == "Move Overhead"{
-						value := words[5]
-						fmt.Println("move overhead: ",value)
-					}
-				} else {
-					fmt.Printf("invalid position command\n")
-				}
-			} else {
-				fmt.Printf("invalid uci command\n")
+			name, value, err := parseOption(strings.Fields(in))
+			if err == nil {
+				err = g.setOption(name, value)
+			}
+			if err != nil {
+				fmt.Println(err)
 			}
 
 		} else if strings.HasPrefix(in, "position") {
@@ -70,24 +378,10 @@ func (g *Game) Run() {
 					if len(words) > 2 && words[2] == "moves" {
 						g.Board = NewBoard(defaultFEN)
 						for _, moveStr := range words[3:] {
-							if m, err := CreateMove(moveStr); err == nil {
-								gen := NewGenerator(g.Board)
-								moves := gen.GenerateMoves()
-					
-								found := Move{From: Invalid}
-					
-								for _, move := range moves {
-									if move.From == m.From && move.To == m.To {
-										found = move
-										break
-									}
-								}
-								if found.From != Invalid {
-									g.Board.MakeMove(found)
-								} else {
-									fmt.Printf("illegal move\n")
-								}
-					
+							if move, err := g.Board.LegalMove(moveStr); err == nil {
+								g.Board.MakeMove(move)
+							} else {
+								fmt.Println(err)
 							}
 						}
 					}
@@ -108,9 +402,23 @@ func (g *Game) Run() {
 		}else if in == "perft" {
 			Perft(position1FEN, position1Table)
 
+		} else if strings.HasPrefix(in, "perft divide") {
+			if err := runPerftDivide(g.Board, strings.Fields(in)[2:]); err != nil {
+				fmt.Println(err)
+			}
+
+		} else if strings.HasPrefix(in, "perftsuite") {
+			if err := runPerftSuite(strings.Fields(in)[1:]); err != nil {
+				fmt.Println(err)
+			}
+
 		} else if in == "perft2" {
 			Perft(position2FEN, position2Table)
 
+		} else if in == "debug on" || in == "debug off" {
+			// perft checks the incremental evaluation in debug mode
+			debugIncremental = in == "debug on"
+
 		} else if in == "ucinewgame" || in == "n" {
 			g := new(Game)
 			g.Board = NewBoard(defaultFEN)
@@ -131,40 +439,47 @@ func (g *Game) Run() {
 			Search(g.Board)
 
 		} else if strings.HasPrefix(in, "go") || in == "g" {
-			move := Search(g.Board)
-			stringMove := SquareMap[move.From] + SquareMap[move.To]
-			fmt.Println("bestmove ", stringMove)
-			g.Board.MakeMove(move)
+			options, err := parseGo(g.Board, strings.Fields(in), g.options)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			options.Skill = g.skill()
+
+			g.startSearch(options)
 
 		} else if in == "eval" || in == "e" {
-			fmt.Printf("Score: %d\n", Evaluate(g.Board))
+			terms, score := EvaluateBreakdown(g.Board)
+			fmt.Print(FormatEvalBreakdown(terms))
+			fmt.Printf("Score: %d\n", score)
+
+		} else if in == "evalcheck" || strings.HasPrefix(in, "evalcheck ") {
+			if err := runEvalCheck(strings.Fields(in)[1:]); err != nil {
+				fmt.Println(err)
+			}
+
+		} else if strings.HasPrefix(in, "tune ") {
+			if err := runTune(strings.Fields(in)[1:]); err != nil {
+				fmt.Println(err)
+			}
 
 		} else if in == "auto" || in == "a" {
-			for g.Board.status == statusNormal {
+			for !gameOver(g.Board.gameStatus()) {
 				g.Board.MakeMove(Search(g.Board))
 				fmt.Printf("%s\n", FormatBoard(g.Board))
 			}
 
-		} else if m, err := CreateMove(in); err == nil {
+		} else if _, err := CreateMove(in); err == nil {
 			fmt.Println("making move")
-			gen := NewGenerator(g.Board)
-			moves := gen.GenerateMoves()
-
-			found := Move{From: Invalid}
-
-			for _, move := range moves {
-				if move.From == m.From && move.To == m.To {
-					found = move
-					break
-				}
-			}
 
-			if found.From != Invalid {
-				g.Board.MakeMove(found)
+			if move, err := g.Board.LegalMove(in); err == nil {
+				g.Board.MakeMove(move)
 			} else {
-				fmt.Printf("illegal move\n")
+				fmt.Println(err)
 			}
 
 		}
 	}
+
+	g.finishSearch()
 }
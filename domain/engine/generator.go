@@ -1,5 +1,7 @@
 package engine
 
+import "math/bits"
+
 var (
 	nextRank int8 = 16
 	nextFile int8 = 1
@@ -20,9 +22,6 @@ var (
 	deltaQueen  = deltaAll
 	deltaKing   = deltaAll
 
-	deltaWhitePawn = []int8{moveUp, moveUpLeft, moveUpRight}       // first move for pawns has to be forward!
-	deltaBlackPawn = []int8{moveDown, moveDownLeft, moveDownRight} // first move for pawns has to be forward!
-
 	whitePawnStartPos int8 = 1 // rank 2
 	blackPawnStartPos int8 = 6 // rank 7
 
@@ -31,36 +30,35 @@ var (
 
 	whiteKingStartSquare = E1
 	blackKingStartSquare = E8
-	whiteRookShortSquare = A1
-	whiteRookLongSquare  = H1
-	blackRookShortSquare = A8
-	blackRookLongSquare  = H8
-)
+	whiteRookShortSquare = H1
+	whiteRookLongSquare  = A1
+	blackRookShortSquare = H8
+	blackRookLongSquare  = A8
 
-// squares that need to be empty and not under check for castling
-var (
-	shortWhiteSquares = []Square{F1, G1}
-	longWhiteSquares  = []Square{B1, C1, D1}
-	shortBlackSquares = []Square{F8, G8}
-	longBlackSquares  = []Square{B8, C8, D8}
+	// promotions in the order they are generated
+	promotionKinds = []int8{Queen, Rook, Bishop, Knight}
 )
 
 const (
 	boardSize int8 = 120
 	size      int8 = 8
+
+	// maxMoves exceeds the 218 moves of the richest known position
+	maxMoves = 256
 )
 
+// MoveBuffer holds the moves of a position, search and perft keep one per ply
+type MoveBuffer [maxMoves]Move
+
 // Generator creates possible moves for a given board position
 type Generator struct {
-	board                  *Board
-	legalEnding            []bool
-	legalDelta             []int8
-	lastMoveSquare         Square
-	moves                  []Move
-	kingSquare             int8
-	kingUnderCheck         bool
-	kingUnderCheckByKnight int8
-	isCheckMate            bool
+	board          *Board
+	lastMoveSquare Square
+	moves          []Move
+	kingSquare     int8
+	kingUnderCheck bool
+	// sorted is the scratch of sortMoves, kept for the next call
+	sorted MoveBuffer
 }
 
 // NewGenerator creates a new generator for a given board
@@ -71,55 +69,71 @@ func NewGenerator(board *Board) *Generator {
 	return g
 }
 
-// GenerateMoves creates a list of possible moves
+// GenerateMoves creates a list of the legal moves
 func (g *Generator) GenerateMoves() []Move {
+	return g.generate(make([]Move, 0, 48))
+}
 
-	g.reset()
+// GenerateMovesInto creates the legal moves in the buffer, a generator reused for the
+// same buffer does not allocate
+func (g *Generator) GenerateMovesInto(buffer *MoveBuffer) []Move {
+	return g.generate(buffer[:0])
+}
 
-	// generation
+func (g *Generator) generate(moves []Move) []Move {
 
-	threats := g.numberOfCheckThreats()
+	g.reset(moves)
 
-	g.generateKingMoves(g.kingSquare)
+	bb := &g.board.bitboards
+	us, them := g.sides()
+	king := to64(g.kingSquare)
+	occupied := bb.occupied()
 
-	// only legal move is moving the king ... else we have a mate
-	if threats > 1 {
-		g.sortMoves()
-		return g.moves
+	checkers := bb.attackersTo(king, them, occupied)
+	g.kingUnderCheck = checkers != 0
+
+	// the king must not stay on the line of a slider it moves away from
+	withoutKing := occupied &^ (uint64(1) << king)
+	for targets := kingAttacks[king] &^ bb.colors[us]; targets != 0 && bb.pieces[us][King] != 0; {
+		to := popLowest(&targets)
+		if bb.attackersTo(to64(to), them, withoutKing) == 0 {
+			g.addMove(g.CreateMove(g.kingSquare, to))
+		}
 	}
 
-	if threats == 1 && g.kingUnderCheckByKnight != Empty {
-		g.generateCaptureMovesForOpponentKnight(g.kingUnderCheckByKnight)
+	// only legal move is moving the king ... else we have a mate
+	if bits.OnesCount64(checkers) > 1 {
 		g.sortMoves()
 		return g.moves
 	}
 
-	if threats == 0 {
+	// a single check is answered by capturing or blocking the checker
+	target := ^bb.colors[us]
+	if checkers != 0 {
+		target &= between[king][bits.TrailingZeros64(checkers)] | checkers
+	} else {
 		g.generateCastlingMoves()
 	}
 
-	// do generation
-
-	for rank := int8(0); rank < size; rank++ {
-		for file := int8(0); file < size; file++ {
-			square := square(rank, file)
-			piece := g.board.data[square] * g.board.sideToMove
-
-			if piece > 0 {
-				switch piece {
-				case Pawn:
-					g.generateMovesPawn(square)
-				case Rook:
-					g.generateGenericMoves(square, deltaRook, false)
-				case Bishop:
-					g.generateGenericMoves(square, deltaBishop, false)
-				case Queen:
-					g.generateGenericMoves(square, deltaQueen, false)
-				case Knight:
-					g.generateGenericMoves(square, deltaKnight, true)
-				}
-			}
+	pinned := bb.pinned(king, us, them)
+
+	for pieces := bb.colors[us] &^ bb.pieces[us][King]; pieces != 0; {
+		from := popLowest(&pieces)
 
+		// pinned pieces move along the line of their pin only
+		allowed := target
+		if pinned&bit(from) != 0 {
+			allowed &= lines[king][to64(from)]
+		}
+
+		kind := abs(g.board.data[from])
+		if kind == Pawn {
+			g.generateMovesPawn(from, allowed, king)
+			continue
+		}
+
+		for targets := pieceAttacks(kind, to64(from), occupied) & allowed; targets != 0; {
+			g.addMove(g.CreateMove(from, popLowest(&targets)))
 		}
 	}
 
@@ -127,174 +141,99 @@ func (g *Generator) GenerateMoves() []Move {
 	return g.moves
 }
 
-// CheckSimple finds possible check attacks
+// CheckSimple checks whether the king of the side to move is attacked
 func (g *Generator) CheckSimple() bool {
 
-	g.reset()
-
-	// check for knight attacks first
-	for _, delta := range deltaKnight {
-		to := g.kingSquare + delta
-		if g.board.legalSquare(to) && g.board.data[to]*opponent(g.board.sideToMove) == Knight {
-			return true
-		}
-	}
-
-	// check for all other pieces
-	for _, delta := range deltaAll {
-		depth := 0
-		for to := g.kingSquare + delta; g.board.legalSquare(to); to += delta {
-			depth++
-			found := g.board.data[to] * opponent(g.board.sideToMove)
-
-			// empty square, go ahead
-			if found == Empty {
-				continue
-			}
-
-			if found == Pawn || found == King {
-				if depth == 1 {
-					if g.attackPossible(to, opposite(delta)) {
-						return true
-					}
-				}
-				break
-			}
-
-			// stop direction if own piece is found
-			if found < 0 {
-				break
-			}
-
-			// found opponent
-			if g.attackPossible(to, delta) {
-				return true
-			}
-			break
-		}
-	}
-
-	return false
+	_, them := g.sides()
+	return g.board.bitboards.attacked(to64(g.ownKing()), them)
 }
 
-func (g *Generator) reset() {
-	g.moves = make([]Move, 0, 48)
+func (g *Generator) reset(moves []Move) {
+	g.moves = moves
 
 	g.kingUnderCheck = false
-	g.kingUnderCheckByKnight = Empty
-
-	g.kingSquare = int8(g.board.whiteKingPosition)
-	if g.board.sideToMove == Black {
-		g.kingSquare = int8(g.board.blackKingPosition)
-	}
 
-	g.legalEnding = make([]bool, boardSize)
-	g.legalDelta = make([]int8, boardSize)
+	g.kingSquare = g.ownKing()
 
 	if len(g.board.history) > 0 {
 		g.lastMoveSquare = g.board.history[len(g.board.history)-1].move.To
 	}
 }
 
-func (g *Generator) sortMoves() {
-	lastCapture := make([]Move, 0, len(g.moves))
-	sorted := make([]Move, 0, len(g.moves))
-	captured := make([]Move, 0, len(g.moves))
-	promotions := make([]Move, 0, len(g.moves))
-	castelings := make([]Move, 0, len(g.moves))
-	ordinary := make([]Move, 0, len(g.moves))
-
-	for _, move := range g.moves {
-		switch {
-		case move.To == g.lastMoveSquare:
-			lastCapture = append(lastCapture, move)
-		case move.Content != Empty:
-			captured = append(captured, move)
-		case move.Special == movePromotion:
-			promotions = append(promotions, move)
-		case move.Special == moveCastelingShort || move.Special == moveCastelingLong:
-			castelings = append(castelings, move)
-		default:
-			ordinary = append(ordinary, move)
-		}
+// ownKing returns the square of the king of the side to move
+func (g *Generator) ownKing() int8 {
+	if g.board.sideToMove == Black {
+		return int8(g.board.blackKingPosition)
 	}
-
-	// 1. last moved piece capture
-	sorted = append(sorted, lastCapture...)
-
-	// 2. capture moves
-	sorted = append(sorted, captured...)
-
-	// 3.promotion moves
-	sorted = append(sorted, promotions...)
-
-	// 4.castling moves
-	sorted = append(sorted, castelings...)
-
-	// 5. normal moves
-	sorted = append(sorted, ordinary...)
-
-	g.moves = sorted
+	return int8(g.board.whiteKingPosition)
 }
 
-func (g *Generator) addMove(move Move) {
-	g.moves = append(g.moves, move)
+// sides returns the bitboard index of the side to move and of its opponent
+func (g *Generator) sides() (int, int) {
+	return sideIndex(g.board.sideToMove), sideIndex(opponent(g.board.sideToMove))
 }
 
-func (g *Generator) generateKingMoves(square int8) {
-	to := int8(0)
-	for _, delta := range deltaKing {
-		to = square + delta
-		if g.board.legalSquare(to) {
-			move := g.CreateMove(square, to)
-			if move.MovedPiece == Empty {
-				continue
-			}
-
-			if len(g.findThreats(Square(to), g.board.sideToMove, true)) == 0 {
-				g.addMove(move)
-			}
-		}
+// sortMoves orders the moves by their category and keeps the order within a category:
+// 1. capture of the last moved piece, 2. captures, 3. promotions, 4. castles, 5. others
+func (g *Generator) sortMoves() {
+	// count the moves per category to find where each category starts
+	var starts [moveCategories + 1]int
+	for _, move := range g.moves {
+		starts[g.moveCategory(move)+1]++
+	}
+	for category := 1; category < moveCategories; category++ {
+		starts[category] += starts[category-1]
 	}
 
+	sorted := g.sorted[:len(g.moves)]
+	for _, move := range g.moves {
+		category := g.moveCategory(move)
+		sorted[starts[category]] = move
+		starts[category]++
+	}
+
+	copy(g.moves, sorted)
 }
 
-func (g *Generator) generateCaptureMovesForOpponentKnight(square int8) {
-	threats := g.findThreats(Square(square), opponent(g.board.sideToMove), false)
+const moveCategories = 5
 
-	if len(threats) >= 0 {
-		for _, threat := range threats {
-			g.addMove(g.CreateMove(threat, square))
-		}
+func (g *Generator) moveCategory(move Move) int {
+	switch {
+	case move.To == g.lastMoveSquare:
+		return 0
+	case move.Content != Empty:
+		return 1
+	case move.Special == movePromotion:
+		return 2
+	case move.Special == moveCastelingShort || move.Special == moveCastelingLong:
+		return 3
 	}
+	return 4
 }
 
-func (g *Generator) generateGenericMoves(from int8, delta []int8, singleStep bool) {
-	for _, d := range delta {
-		for to := from + d; g.board.legalSquare(to); to += d {
-			move := g.CreateMove(from, to)
-			// we are facing the same color; skip
-			if move.MovedPiece == Empty {
-				break
-			}
-			if g.legalDelta[from] == 0 || (g.legalDelta[from] == d || g.legalDelta[from] == opposite(d)) {
-				if !g.kingUnderCheck || g.legalEnding[to] {
-					g.addMove(move)
-				}
-			} else {
-				break
-			}
-			// this move was single step only or we captured a piece; no need to go further then
-			if singleStep || move.Content != Empty {
-				break
-			}
-		}
+func (g *Generator) addMove(move Move) {
+	g.moves = append(g.moves, move)
+}
+
+// pieceAttacks returns the squares a knight, bishop, rook or queen attacks
+func pieceAttacks(kind int8, sq int, occupied uint64) uint64 {
+	switch kind {
+	case Knight:
+		return knightAttacks[sq]
+	case Bishop:
+		return bishopAttacks(sq, occupied)
+	case Rook:
+		return rookAttacks(sq, occupied)
+	case Queen:
+		return rookAttacks(sq, occupied) | bishopAttacks(sq, occupied)
 	}
+	return 0
 }
 
+// CreateMove creates the move of the piece on a square to another square. The moved
+// piece is empty if a piece of the same color occupies the target.
 func (g *Generator) CreateMove(from, to int8) Move {
-	move := Move{From: Square(from), To: Square(to)}
-	move.Promoted = Empty
+	move := Move{From: Square(from), To: Square(to), Promoted: Empty}
 	move.Content = g.board.data[to]
 
 	// piece of same color on to square
@@ -304,315 +243,101 @@ func (g *Generator) CreateMove(from, to int8) Move {
 	}
 
 	move.MovedPiece = g.board.data[from]
-	move.From = Square(from)
-	move.To = Square(to)
-
-	switch {
-	case move.MovedPiece == WhiteKing || move.MovedPiece == WhiteRook:
-		move.Promoted = g.board.whiteCastle
-	case move.MovedPiece == BlackKing || move.MovedPiece == BlackRook:
-		move.Promoted = g.board.blackCastle
-	}
 
 	return move
 }
 
-func (g *Generator) generateMovesPawn(from int8) {
+func (g *Generator) generateMovesPawn(from int8, allowed uint64, king int) {
+	bb := &g.board.bitboards
+	us, them := g.sides()
+	pawn := g.board.data[from]
+	forward := pawn * nextRank
 
-	startPos := false
-	delta := deltaWhitePawn
-
-	switch g.board.data[from] {
-	case WhitePawn:
-		startPos = rank(from) == whitePawnStartPos
-	case BlackPawn:
+	startPos := rank(from) == whitePawnStartPos
+	if pawn == BlackPawn {
 		startPos = rank(from) == blackPawnStartPos
-		delta = deltaBlackPawn
 	}
 
-	for _, d := range delta {
-		to := from + d
-
-		if g.board.legalSquare(to) {
-			// first check if it's has the direction to go and then if it is a legal ending
-			if g.legalDelta[from] == 0 || (g.legalDelta[from] == d || g.legalDelta[from] == opposite(d)) {
-				g.generateSingleMovePawn(from, to, startPos)
-			}
+	// moving forward requires empty squares
+	if to := from + forward; g.board.data[to] == Empty {
+		if allowed&bit(to) != 0 {
+			g.addPawnMove(g.CreateMove(from, to))
+		}
+		if to2 := to + forward; startPos && g.board.data[to2] == Empty && allowed&bit(to2) != 0 {
+			g.addMove(g.CreateMove(from, to2))
 		}
 	}
-}
 
-func (g *Generator) generateSingleMovePawn(from int8, to int8, startingPos bool) {
-	move := Move{}
-
-	move.MovedPiece = g.board.data[from]
-	move.Content = g.board.data[to]
-	move.From = Square(from)
-	move.To = Square(to)
-	move.Promoted = Empty
-
-	enPassantRemovesThreat := false
-
-	if g.kingUnderCheck && g.board.enPassant == Square(to) {
-		enPassantRemovesThreat = g.legalEnding[to+(moveDown*g.board.sideToMove)]
+	attacks := pawnAttacks[us][to64(from)]
+	for targets := attacks & bb.colors[them] & allowed; targets != 0; {
+		g.addPawnMove(g.CreateMove(from, popLowest(&targets)))
 	}
 
-	if (!g.kingUnderCheck || g.legalEnding[to]) || enPassantRemovesThreat {
-		// move left/right
-		if file(from) != file(to) {
-
-			// en passant?
-			if move.To == g.board.enPassant {
-				move.Special = moveEnPassant
-				move.Content = -move.MovedPiece
-
-			} else if g.board.data[from]*g.board.data[to] >= 0 {
-				// must be opposite pawn
-				return
-			}
+	// en passant removes two pawns from their squares, which may uncover the king
+	if ep := int8(g.board.enPassant); g.board.enPassant != Invalid && attacks&bit(ep) != 0 {
+		captured := ep - forward
+		occupied := bb.occupied() ^ bit(from) ^ bit(ep) ^ bit(captured)
 
-		} else if g.board.data[to] != Empty {
-			// moving forward requires an empty field
-			return
-		}
-
-		// promotions (queen or rook are only relevant pieces to check)
-		if rank(to)%7 == 0 {
-			move.Promoted = move.MovedPiece * Queen
-			move.Special = movePromotion
-			g.addMove(move)
-
-			move.Promoted = move.MovedPiece * Rook
-			move.Special = movePromotion
-			g.addMove(move)
-
-		} else {
+		if bb.attackersTo(king, them, occupied)&^bit(captured) == 0 {
+			move := g.CreateMove(from, ep)
+			move.Special = moveEnPassant
+			move.Content = -pawn
 			g.addMove(move)
 		}
 	}
+}
 
-	// starting position; do two steps if on same file; do not care about direction
-	if startingPos && file(from) == file(to) && g.board.data[to] == Empty {
-		move.To = Square(to + to - from)
-		if g.board.data[move.To] == Empty && (!g.kingUnderCheck || g.legalEnding[move.To]) {
-			g.addMove(move)
-		}
+// addPawnMove adds a pawn move, reaching the last rank it adds all promotions
+func (g *Generator) addPawnMove(move Move) {
+	if r := rank(int8(move.To)); r != 0 && r != size-1 {
+		g.addMove(move)
+		return
 	}
 
+	move.Special = movePromotion
+	for _, kind := range promotionKinds {
+		move.Promoted = move.MovedPiece * kind
+		g.addMove(move)
+	}
 }
 
 func (g *Generator) generateCastlingMoves() {
 	// assume king is not under check
 	switch g.board.sideToMove {
 	case White:
-		if g.canCastle(g.board.sideToMove, castleShort) {
+		if g.canCastle(g.board.whiteCastle, castleShort, E1, H1, []Square{F1, G1}) {
 			g.addMove(Move{From: E1, To: G1, Content: Empty, MovedPiece: WhiteKing, Special: moveCastelingShort})
 		}
-		if g.canCastle(g.board.sideToMove, castleLong) {
+		if g.canCastle(g.board.whiteCastle, castleLong, E1, A1, []Square{D1, C1}) {
 			g.addMove(Move{From: E1, To: C1, Content: Empty, MovedPiece: WhiteKing, Special: moveCastelingLong})
 		}
 	case Black:
-		if g.canCastle(g.board.sideToMove, castleShort) {
+		if g.canCastle(g.board.blackCastle, castleShort, E8, H8, []Square{F8, G8}) {
 			g.addMove(Move{From: E8, To: G8, Content: Empty, MovedPiece: BlackKing, Special: moveCastelingShort})
 		}
-		if g.canCastle(g.board.sideToMove, castleLong) {
+		if g.canCastle(g.board.blackCastle, castleLong, E8, A8, []Square{D8, C8}) {
 			g.addMove(Move{From: E8, To: C8, Content: Empty, MovedPiece: BlackKing, Special: moveCastelingLong})
 		}
 	}
 }
 
-func (g *Generator) canCastle(color int8, dir int8) bool {
-	switch color {
-	case White:
-		if g.board.whiteCastle&dir == dir {
-			if dir == castleShort {
-				return g.board.isEmpty(F1, G1) && len(g.findThreats(F1, color, false)) == 0 && len(g.findThreats(G1, color, false)) == 0
-			}
-			return g.board.isEmpty(B1, C1, D1) && len(g.findThreats(C1, color, false)) == 0 && len(g.findThreats(D1, color, false)) == 0
-		}
-	case Black:
-		if g.board.blackCastle&dir == dir {
-			if dir == castleShort {
-				return g.board.isEmpty(F8, G8) && len(g.findThreats(F8, color, false)) == 0 && len(g.findThreats(G8, color, false)) == 0
-			}
-			return g.board.isEmpty(B8, C8, D8) && len(g.findThreats(C8, color, false)) == 0 && len(g.findThreats(D8, color, false)) == 0
-		}
+// canCastle checks the right, the rook and that the squares between king and rook are
+// empty and the squares the king passes are not attacked
+func (g *Generator) canCastle(rights int8, dir int8, king Square, rook Square, passed []Square) bool {
+	if rights&dir == 0 || g.board.data[rook] != g.board.data[king]/King*Rook {
+		return false
 	}
 
-	return false
-}
-
-func (g *Generator) findThreats(square Square, sideToMove int8, skipKing bool) []int8 {
-
-	threats := []int8{}
-
-	// knights need some special treatment
-	for _, delta := range deltaKnight {
-		to := int8(square) + delta
-		if g.board.legalSquare(to) && g.board.data[to]*opponent(sideToMove) == Knight {
-			threats = append(threats, to)
-		}
-	}
-
-	// check all possible directions
-	for _, delta := range deltaAll {
-		depth := 0
-		for to := int8(square) + delta; g.board.legalSquare(to); to += delta {
-			depth++
-			content := g.board.data[to] * sideToMove
-
-			// if there is a pawn/king on next square, check for attack
-			if content == -Pawn || content == -King {
-				if depth == 1 {
-					if g.attackPossible(to, opposite(delta)) {
-						threats = append(threats, to)
-					}
-				}
-				break
-			}
-
-			// skip all empty squares
-			if content == Empty {
-				continue
-			}
-
-			if skipKing && content == King {
-				continue
-			}
-
-			// own piece
-			if content > 0 {
-				break
-			}
-
-			// found opponent piece
-			if g.attackPossible(to, delta) {
-				threats = append(threats, to)
-			}
-
-			// no point in checking further
-			break
-		}
-	}
-
-	return threats
-}
-
-// find the threats for the current color's king possition
-func (g *Generator) numberOfCheckThreats() int {
-	color := g.board.sideToMove
-	threats := 0
-	depth := 0
-	squareOfGuardingPiece := Invalid
-
-	// check if there is a threat from an opponents knight (could only be one)
-	for _, delta := range deltaKnight {
-		newSquare := g.kingSquare + delta
-
-		if g.board.legalSquare(newSquare) && g.board.data[newSquare]*opponent(color) == Knight {
-			g.kingUnderCheck = true
-			g.kingUnderCheckByKnight = int8(newSquare)
-			threats++
-			break
-		}
-	}
-
-	// checking all possible directions
-	for _, delta := range deltaAll {
-		depth = 0
-		squareOfGuardingPiece = Invalid
-
-		for newSquare := g.kingSquare + delta; g.board.legalSquare(newSquare); newSquare += delta {
-			depth++
-
-			// check found piece
-			// positive: opponent
-			// zero:     empty
-			// negative: same color
-			piece := g.board.data[newSquare] * opponent(color)
-
-			if piece == Empty {
-				continue
-			}
-
-			// pawn on the next square, check if attack possible
-			if piece == Pawn || piece == King {
-				if depth == 1 {
-					if g.attackPossible(newSquare, opposite(delta)) {
-						g.kingUnderCheck = true
-						threats++
-						g.legalEnding[newSquare] = true
-					}
-				}
-				break
-			}
-
-			// own piece -> we stop but might have a protecting piece
-			if piece < 0 {
-				if squareOfGuardingPiece == Invalid {
-					squareOfGuardingPiece = Square(newSquare)
-					continue
-				} else {
-					break
-				}
-			}
-
-			// we found an opponents piece; might be a threat for the current square
-			if g.attackPossible(newSquare, delta) {
-				if squareOfGuardingPiece == Invalid {
-					threats++
-					g.kingUnderCheck = true
-					g.legalEnding[newSquare] = true
-
-					// set legal endings; looks hack-ish: it is!
-					for i := g.kingSquare + delta; i != newSquare; i += delta {
-						g.legalEnding[i] = true
-					}
-				} else {
-					g.legalDelta[uint8(squareOfGuardingPiece)] = delta
-				}
-			}
-		}
-	}
-
-	return threats
-}
-
-// check wether a piece can move towards a specific direction
-// does not work for knight obviously
-func (g *Generator) hasDirection(delta []int8, direction int8) bool {
-	for _, d := range delta {
-		if d == direction {
-			return true
-		}
+	occupied := g.board.bitboards.occupied()
+	if between[to64(int8(king))][to64(int8(rook))]&occupied != 0 {
+		return false
 	}
 
-	return false
-}
-
-// check for attacks from a diven square in a particular direction
-// does not work for knight obviously
-func (g *Generator) attackPossible(from int8, direction int8) bool {
-	absPiece := abs(g.board.data[from])
-
-	switch absPiece {
-	case King:
-		return g.hasDirection(deltaKing, direction)
-	case Queen:
-		return g.hasDirection(deltaQueen, direction)
-	case Rook:
-		return g.hasDirection(deltaRook, direction)
-	case Bishop:
-		return g.hasDirection(deltaBishop, direction)
-	case Pawn:
-		// remove the first move of pawns (move foreward = no attack move!)
-		switch g.board.data[from] {
-		case WhitePawn:
-			return g.hasDirection(deltaWhitePawn[1:], direction)
-		case BlackPawn:
-			return g.hasDirection(deltaBlackPawn[1:], direction)
+	_, them := g.sides()
+	for _, sq := range passed {
+		if g.board.bitboards.attacked(to64(int8(sq)), them) {
+			return false
 		}
 	}
-
-	return false
+	return true
 }
@@ -1,6 +1,9 @@
 package engine
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestGenerateMovesForDefaultBoardPosition(t *testing.T) {
 
@@ -91,6 +94,35 @@ func doTestMovesForFEN(fen string, expected []Move, t *testing.T) {
 	}
 }
 
+func TestGenerateMovesIntoDoesNotAllocate(t *testing.T) {
+	b := NewBoard(position2FEN)
+	generator := NewGenerator(b)
+	var buffer MoveBuffer
+
+	moves := generator.GenerateMovesInto(&buffer)
+	if expected := NewGenerator(b).GenerateMoves(); !reflect.DeepEqual(moves, expected) {
+		t.Errorf("Expected the moves %v but generated %v\n", expected, moves)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		generator.GenerateMovesInto(&buffer)
+		generator.CheckSimple()
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations but found %.1f per run\n", allocs)
+	}
+}
+
+func BenchmarkGenerateMoves(b *testing.B) {
+	generator := NewGenerator(NewBoard(position2FEN))
+	var buffer MoveBuffer
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		generator.GenerateMovesInto(&buffer)
+	}
+}
+
 func contains(moves []Move, move Move) bool {
 
 	for _, m := range moves {
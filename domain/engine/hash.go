@@ -18,9 +18,14 @@ type ZobristTable struct {
 	hashCurrent        int64
 }
 
+// zobristKeys are shared by all boards, so hashes of different boards compare
+var zobristKeys = NewZobristTable()
+
+// NewZobristTable creates the keys from a fixed seed, without touching the global source
 func NewZobristTable() *ZobristTable {
 
-	rand.Seed(4711)
+	random := rand.New(rand.NewSource(4711))
+	hashRand := random.Int63
 
 	z := ZobristTable{}
 
@@ -37,7 +42,7 @@ func NewZobristTable() *ZobristTable {
 		z.hashEnPassant[square] = hashRand()
 	}
 	// castling options
-	for i := int8(0); i <= castleLong; i++ {
+	for i := 0; i < numCastelings; i++ {
 		z.hashCastelingBlack[i] = hashRand()
 		z.hashCastelingWhite[i] = hashRand()
 	}
@@ -52,7 +57,3 @@ func NewZobristTable() *ZobristTable {
 
 	return &z
 }
-
-func hashRand() int64 {
-	return rand.Int63()
-}
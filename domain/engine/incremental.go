@@ -0,0 +1,148 @@
+package engine
+
+import "fmt"
+
+// debugIncremental compares the incremental evaluation with a full rescan during perft
+var debugIncremental bool
+
+// boardEval holds the material, the piece counts and the piece square scores of a board.
+// The moves of the board keep it up to date for the parameters it was computed with.
+type boardEval struct {
+	params       *EvalParams
+	counts       materialCount
+	material     [2]EvalWeight
+	pieceSquares [2]EvalWeight
+	phase        int
+}
+
+// newBoardEval scans all pieces of the board
+func newBoardEval(b *Board, p *EvalParams) boardEval {
+	e := boardEval{params: p}
+	for sq := int8(0); sq < boardSize; sq++ {
+		if b.legalSquare(sq) && b.data[sq] != Empty {
+			e.update(b.data[sq], sq, 1)
+		}
+	}
+	return e
+}
+
+// update adds or removes a piece on a square, kings only count by their square
+func (e *boardEval) update(piece int8, sq int8, sign int) {
+	side, index := 0, sq
+	if piece < 0 {
+		side, index = 1, int8(flipTable[sq])
+	}
+	kind := abs(piece)
+
+	tableMiddle, tableEnd := e.params.tables(kind)
+	e.pieceSquares[side].Middle += sign * tableMiddle[index]
+	e.pieceSquares[side].End += sign * tableEnd[index]
+
+	if kind == King {
+		return
+	}
+
+	value := e.params.pieceValue(kind)
+	e.material[side].Middle += sign * value.Middle
+	e.material[side].End += sign * value.End
+	e.counts[side][kind] += sign
+	e.phase += sign * piecePhase[kind]
+}
+
+// pieceChange is a piece a move removes from (-1) or places on (+1) a square
+type pieceChange struct {
+	piece, sq, sign int8
+}
+
+// pieceChanges lists the changes of a move in the order they happen, a castle has four
+type pieceChanges struct {
+	list [4]pieceChange
+	n    int8
+}
+
+func (c *pieceChanges) add(piece int8, sq int8, sign int8) {
+	c.list[c.n] = pieceChange{piece: piece, sq: sq, sign: sign}
+	c.n++
+}
+
+func (c *pieceChanges) all() []pieceChange {
+	return c.list[:c.n]
+}
+
+// changesOf lists every piece a move removes and places. The board, the bitboards, the
+// hashes and the scores all follow the same list.
+func changesOf(m Move) pieceChanges {
+	var c pieceChanges
+	c.add(m.MovedPiece, int8(m.From), -1)
+
+	switch m.Special {
+	case moveOrdinary, movePromotion:
+		if m.Content != Empty {
+			c.add(m.Content, int8(m.To), -1)
+		}
+		placed := m.MovedPiece
+		if m.Special == movePromotion {
+			placed = m.Promoted
+		}
+		c.add(placed, int8(m.To), 1)
+	case moveEnPassant:
+		c.add(-m.MovedPiece, int8(m.To)-m.MovedPiece*nextRank, -1)
+		c.add(m.MovedPiece, int8(m.To), 1)
+	case moveCastelingShort, moveCastelingLong:
+		rook := m.MovedPiece / King * Rook
+		rookFrom, rookTo := int8(m.From)+castleShortDistanceRook*nextFile, int8(m.From)+nextFile
+		if m.Special == moveCastelingLong {
+			rookFrom, rookTo = int8(m.From)-castleLongDistanceRook*nextFile, int8(m.From)-nextFile
+		}
+		c.add(m.MovedPiece, int8(m.To), 1)
+		c.add(rook, rookFrom, -1)
+		c.add(rook, rookTo, 1)
+	}
+	return c
+}
+
+// forEachChange calls apply for every piece a move removes (-1) and places (+1)
+func forEachChange(m Move, apply func(piece int8, sq int8, sign int)) {
+	changes := changesOf(m)
+	for _, c := range changes.all() {
+		apply(c.piece, c.sq, int(c.sign))
+	}
+}
+
+// apply applies the changes of a move to the scores, or takes them back with a negative sign
+func (e *boardEval) apply(changes *pieceChanges, sign int) {
+	if e.params == nil {
+		return
+	}
+	for _, c := range changes.all() {
+		e.update(c.piece, c.sq, sign*int(c.sign))
+	}
+}
+
+// setEvalParams computes the incremental scores of the board for other parameters
+func (b *Board) setEvalParams(p *EvalParams) {
+	if b.eval.params != p {
+		b.eval = newBoardEval(b, p)
+	}
+}
+
+// evaluation returns the scores of the board for the parameters, by a rescan if the
+// board keeps them for other parameters
+func (b *Board) evaluation(p *EvalParams) *boardEval {
+	if b.eval.params == p {
+		return &b.eval
+	}
+	e := newBoardEval(b, p)
+	return &e
+}
+
+// checkIncremental compares the incremental scores with a rescan of the board
+func (b *Board) checkIncremental() error {
+	if b.eval.params == nil {
+		return nil
+	}
+	if scan := newBoardEval(b, b.eval.params); scan != b.eval {
+		return fmt.Errorf("incremental evaluation drifted: %+v instead of %+v", b.eval, scan)
+	}
+	return nil
+}
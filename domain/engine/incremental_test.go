@@ -0,0 +1,45 @@
+package engine
+
+import "testing"
+
+func TestPerftKeepsIncrementalEvaluation(t *testing.T) {
+	debugIncremental = true
+	defer func() { debugIncremental = false }()
+
+	// perft panics once the incremental evaluation differs from a rescan
+	fens := []string{
+		position2FEN,
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+	}
+
+	for _, fen := range fens {
+		perft(3, NewBoard(fen))
+	}
+}
+
+func TestIncrementalEvaluationMatchesRescan(t *testing.T) {
+	b := NewBoard(position2FEN)
+	for _, move := range NewGenerator(b).GenerateMoves() {
+		b.MakeMove(move)
+
+		// other parameters are not kept by the board and rescan it
+		params := defaultEvalParams.clone()
+		if incremental, rescan := Evaluate(b), evaluateTerms(b, nil, &params, &evalTerms{}); incremental != rescan {
+			t.Errorf("Expected %d after %s but found %d\n", rescan, move.UciString(), incremental)
+		}
+		b.UndoMove()
+	}
+}
+
+func TestCheckIncrementalFindsDrift(t *testing.T) {
+	b := NewBoard(defaultFEN)
+	if err := b.checkIncremental(); err != nil {
+		t.Fatal(err)
+	}
+
+	b.data[E2] = Empty
+	if err := b.checkIncremental(); err == nil {
+		t.Error("Expected a removed pawn to be found")
+	}
+}
@@ -0,0 +1,87 @@
+package engine
+
+import "sync"
+
+// SearchJob is a search running on its own goroutine
+type SearchJob struct {
+	control  *searchControl
+	tm       *timeManager
+	board    *Board
+	lines    []SearchLine
+	done     chan struct{}
+	released chan struct{}
+	release  sync.Once
+}
+
+// StartSearch starts a search in the background and returns immediately.
+// A ponder search keeps its result until PonderHit or Stop is called.
+func StartSearch(board *Board, options SearchOptions) *SearchJob {
+	return newSearchJob(board, options, systemClock{})
+}
+
+// newSearchJob starts a search job that takes its time from the clock
+func newSearchJob(board *Board, options SearchOptions, c clock) *SearchJob {
+	j := &SearchJob{
+		control:  newSearchControl(c),
+		tm:       newTimeManager(board, options),
+		done:     make(chan struct{}),
+		released: make(chan struct{}),
+	}
+
+	// the search works on a board of its own
+	j.board = board.Clone()
+
+	if !options.Ponder {
+		j.release.Do(func() { close(j.released) })
+	}
+
+	go func() {
+		j.lines = searchLines(j.board, options, j.control, j.tm)
+		close(j.done)
+	}()
+
+	return j
+}
+
+// Stop ends the search as soon as possible
+func (j *SearchJob) Stop() {
+	j.control.stopped.Store(true)
+	j.release.Do(func() { close(j.released) })
+}
+
+// PonderHit turns a ponder search into a normal timed search starting now
+func (j *SearchJob) PonderHit() {
+	j.control.start(j.tm)
+	j.release.Do(func() { close(j.released) })
+}
+
+// Pondering checks whether the job is still waiting for a ponder hit
+func (j *SearchJob) Pondering() bool {
+	select {
+	case <-j.released:
+		return false
+	default:
+		return true
+	}
+}
+
+// Wait blocks until the search has finished and returns its lines
+func (j *SearchJob) Wait() []SearchLine {
+	<-j.done
+	<-j.released
+	return j.lines
+}
+
+// Searches checks whether the job searches the position of the given board reached by the
+// same moves. The FEN alone misses the history, which decides repetitions in the search.
+func (j *SearchJob) Searches(b *Board) bool {
+	if j.board.Hash() != b.Hash() || generateFEN(j.board) != generateFEN(b) || len(j.board.history) != len(b.history) {
+		return false
+	}
+	for i, item := range b.history {
+		if j.board.history[i].hash != item.hash {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package engine
+
+const (
+	// a single attacker is not dangerous yet
+	evalKingAttackersMin = 2
+)
+
+var (
+	// attack units of a piece per attacked square next to the enemy king, indexed by piece
+	kingAttackUnits = []int{0, 0, 2, 2, 3, 5}
+
+	// king safety penalty by the attack units of the opponent
+	kingAttackTable = []int{
+		0, 0, 1, 2, 3, 5, 7, 9, 12, 15,
+		18, 22, 26, 30, 35, 39, 44, 50, 56, 62,
+		68, 75, 82, 85, 89, 97, 105, 113, 122, 131,
+		140, 150, 169, 180, 191, 202, 213, 225, 237, 248,
+		260, 272, 283, 295, 307, 319, 330, 342, 354, 366,
+		377, 389, 401, 412, 424, 436, 448, 459, 471, 483,
+		494, 500, 500, 500, 500, 500, 500, 500, 500, 500,
+	}
+
+	// bonus of the own pawn closest in front of the king by its distance, zero for none
+	pawnShield = []int{-15, 12, 6, -5, -10, -15, -15, -15}
+	// penalty of the enemy pawn closest in front of the king by its distance, zero for none
+	pawnStorm = []int{0, -5, -20, -12, -6, 0, 0, 0}
+)
+
+// kingAttackPenalty returns the midgame penalty of a king attacked by the given units
+func kingAttackPenalty(units, attackers int, p *EvalParams) int {
+	if attackers < p.KingAttackersMin {
+		return 0
+	}
+	if units >= len(p.KingAttackTable) {
+		units = len(p.KingAttackTable) - 1
+	}
+	return -p.KingAttackTable[units]
+}
+
+// evaluatePawnShelter scores the own pawns shielding the king and the enemy pawns storming
+// it on the file of the king and the adjacent files, for the midgame only
+func evaluatePawnShelter(b *Board, sq int8, p *EvalParams) int {
+	king := b.data[sq]
+	forward := (king / King) * nextRank
+
+	// a king on the edge is sheltered by the same files as next to it
+	center := file(sq)
+	if center == 0 {
+		center = 1
+	} else if center == size-1 {
+		center = size - 2
+	}
+
+	score := 0
+	for f := center - 1; f <= center+1; f++ {
+		shield, storm := 0, 0
+		for d, target := 1, square(rank(sq), f)+forward; b.legalSquare(target); d, target = d+1, target+forward {
+			content := b.data[target]
+			if content == king/King*Pawn && shield == 0 {
+				shield = d
+			}
+			if content == -king/King*Pawn && storm == 0 {
+				storm = d
+			}
+		}
+		score += p.PawnShield[shield] + p.PawnStorm[storm]
+	}
+
+	return score
+}
@@ -0,0 +1,178 @@
+package engine
+
+import "math/bits"
+
+// LegalMove parses a move in UCI notation and completes it from the board, a missing
+// promotion piece promotes to a queen and any other move must not name one. An illegal
+// move returns an *IllegalMoveError.
+func (b *Board) LegalMove(str string) (Move, error) {
+	m, err := CreateMove(str)
+	if err != nil {
+		return Move{}, &IllegalMoveError{Move: str, Reason: IllegalNotation, Message: "moves are written like e2e4 or e7e8q"}
+	}
+
+	move := b.completeMove(m.From, m.To, m.Promoted)
+	if !b.IsLegal(move) {
+		if explanation := b.ExplainMove(m.From, m.To); explanation != nil {
+			explanation.Move = str
+			return Move{}, explanation
+		}
+		return Move{}, &IllegalMoveError{Move: str, Reason: IllegalMovement, Message: "the move is not possible"}
+	}
+	if m.Promoted != Empty && move.Special != movePromotion {
+		return Move{}, &IllegalMoveError{Move: str, Reason: IllegalPromotion, Message: "only a pawn reaching the last rank promotes"}
+	}
+	return move, nil
+}
+
+// completeMove creates the move of the piece on a square with the captured piece and
+// the special kind the board implies
+func (b *Board) completeMove(from, to Square, promoted int8) Move {
+	m := Move{From: from, To: to, MovedPiece: b.data[from], Content: b.data[to], Promoted: Empty}
+
+	switch abs(m.MovedPiece) {
+	case King:
+		if to-from == 2*Square(nextFile) {
+			m.Special = moveCastelingShort
+		} else if from-to == 2*Square(nextFile) {
+			m.Special = moveCastelingLong
+		}
+	case Pawn:
+		if r := rank(int8(to)); r == 0 || r == size-1 {
+			if promoted == Empty {
+				promoted = Queen
+			}
+			m.Special = movePromotion
+			m.Promoted = m.MovedPiece * promoted
+		} else if to == b.enPassant && file(int8(to)) != file(int8(from)) {
+			m.Special = moveEnPassant
+			m.Content = -m.MovedPiece
+		}
+	}
+	return m
+}
+
+// IsLegal checks a move of the side to move against the board without generating the
+// other moves
+func (b *Board) IsLegal(m Move) bool {
+	if !b.legalSquare(int8(m.From)) || !b.legalSquare(int8(m.To)) || m.MovedPiece*b.sideToMove <= 0 {
+		return false
+	}
+	// the pieces and the special kind follow from the board
+	if m != b.completeMove(m.From, m.To, abs(m.Promoted)) {
+		return false
+	}
+	if m.Special == movePromotion && (abs(m.Promoted) < Knight || abs(m.Promoted) > Queen) {
+		return false
+	}
+
+	bb := &b.bitboards
+	us, them := sideIndex(b.sideToMove), sideIndex(opponent(b.sideToMove))
+	if bb.colors[us]&bit(int8(m.To)) != 0 {
+		return false
+	}
+
+	switch m.Special {
+	case moveCastelingShort, moveCastelingLong:
+		return b.isLegalCastle(m)
+	}
+	if !b.reaches(m) {
+		return false
+	}
+
+	// the own king must not be attacked after the move
+	after := *bb
+	after.move(m)
+	king, ok := after.king(us)
+	return !ok || after.attackersTo(king, them, after.occupied()) == 0
+}
+
+// reaches checks whether the moved piece gets from one square to the other
+func (b *Board) reaches(m Move) bool {
+	bb := &b.bitboards
+	from, to := int8(m.From), int8(m.To)
+	occupied := bb.occupied()
+
+	switch kind := abs(m.MovedPiece); kind {
+	case Pawn:
+		forward := m.MovedPiece * nextRank
+		if m.Content != Empty {
+			return pawnAttacks[sideIndex(b.sideToMove)][to64(from)]&bit(to) != 0
+		}
+		if to == from+forward {
+			return true
+		}
+		start := whitePawnStartPos
+		if b.sideToMove == Black {
+			start = blackPawnStartPos
+		}
+		return to == from+2*forward && rank(from) == start && b.data[from+forward] == Empty
+	case King:
+		return kingAttacks[to64(from)]&bit(to) != 0
+	default:
+		return pieceAttacks(kind, to64(from), occupied)&bit(to) != 0
+	}
+}
+
+// isLegalCastle checks a castle against the castles the generator creates
+func (b *Board) isLegalCastle(m Move) bool {
+	if b.InCheck() {
+		return false
+	}
+
+	var castles [2]Move
+	g := Generator{board: b, moves: castles[:0]}
+	g.generateCastlingMoves()
+	for _, castle := range g.moves {
+		if castle == m {
+			return true
+		}
+	}
+	return false
+}
+
+// GivesCheck checks whether a legal move attacks the king of the opponent
+func (b *Board) GivesCheck(m Move) bool {
+	us, them := sideIndex(b.sideToMove), sideIndex(opponent(b.sideToMove))
+
+	after := b.bitboards
+	after.move(m)
+	king, ok := after.king(them)
+	return ok && after.attackersTo(king, us, after.occupied()) != 0
+}
+
+// InCheck checks whether the king of the side to move is attacked
+func (b *Board) InCheck() bool {
+	return b.checkers() != 0
+}
+
+// Checkers returns the squares of the pieces attacking the king of the side to move
+func (b *Board) Checkers() []Square {
+	return squaresOf(b.checkers())
+}
+
+func (b *Board) checkers() uint64 {
+	us, them := sideIndex(b.sideToMove), sideIndex(opponent(b.sideToMove))
+	king, ok := b.bitboards.king(us)
+	if !ok {
+		return 0
+	}
+	return b.bitboards.attackersTo(king, them, b.bitboards.occupied())
+}
+
+// Pinned returns the squares of the pieces of a color that may not leave the line
+// between their king and an attacking slider
+func (b *Board) Pinned(color int8) []Square {
+	us, them := sideIndex(color), sideIndex(opponent(color))
+	king, ok := b.bitboards.king(us)
+	if !ok {
+		return []Square{}
+	}
+	return squaresOf(b.bitboards.pinned(king, us, them))
+}
+
+// king returns the square of the king of a side, boards in tests may lack it
+func (bb *bitboards) king(side int) (int, bool) {
+	kings := bb.pieces[side][King]
+	return bits.TrailingZeros64(kings), kings != 0
+}
@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsLegalMatchesGenerator(t *testing.T) {
+	fens := []string{
+		position2FEN,
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		"8/8/8/K2pP2r/8/8/8/7k w - d6 0 1",
+	}
+
+	for _, fen := range fens {
+		b := NewBoard(fen)
+		checkIsLegal(t, b)
+		for _, move := range NewGenerator(b).GenerateMoves() {
+			b.MakeMove(move)
+			checkIsLegal(t, b)
+			b.UndoMove()
+		}
+	}
+}
+
+func TestIsLegalRejectsInconsistentMoves(t *testing.T) {
+	b := NewBoard(defaultFEN)
+	move, err := b.LegalMove("e2e4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	moves := []Move{
+		{From: E2, To: E4, MovedPiece: WhiteQueen, Promoted: Empty},
+		{From: E2, To: E4, MovedPiece: WhitePawn, Special: moveEnPassant, Promoted: Empty},
+		{From: E7, To: E5, MovedPiece: BlackPawn, Promoted: Empty},
+		{From: E1, To: E2, MovedPiece: WhiteKing, Content: WhitePawn, Promoted: Empty},
+	}
+	if !b.IsLegal(move) {
+		t.Errorf("Expected %+v to be legal\n", move)
+	}
+	for _, m := range moves {
+		if b.IsLegal(m) {
+			t.Errorf("Expected %+v to be illegal\n", m)
+		}
+	}
+}
+
+func TestEnPassantDiscoveredCheck(t *testing.T) {
+	// taking en passant clears the rank between the rook and the king
+	if _, err := NewBoard("8/8/8/K2pP2r/8/8/8/7k w - d6 0 1").LegalMove("e5d6"); err == nil {
+		t.Error("Expected the en passant capture to expose the king")
+	}
+
+	b := NewBoard("8/8/8/1k1pP2R/8/8/8/4K3 w - d6 0 1")
+	move, err := b.LegalMove("e5d6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.GivesCheck(move) {
+		t.Error("Expected the en passant capture to give a discovered check")
+	}
+}
+
+func TestCastlingThroughAttackedSquares(t *testing.T) {
+	b := NewBoard("r3k2r/8/8/8/8/8/5r2/R3K2R w KQkq - 0 1")
+	if _, err := b.LegalMove("e1g1"); err == nil {
+		t.Error("Expected the king not to castle through the attacked f1")
+	}
+	if _, err := b.LegalMove("e1c1"); err != nil {
+		t.Error(err)
+	}
+
+	b = NewBoard("4k3/4r3/8/8/8/8/8/R3K2R w KQ - 0 1")
+	if !b.InCheck() || !reflect.DeepEqual(b.Checkers(), []Square{E7}) {
+		t.Errorf("Expected a check by the rook on e7 but found %v\n", b.Checkers())
+	}
+	if _, err := b.LegalMove("e1c1"); err == nil {
+		t.Error("Expected the king not to castle out of check")
+	}
+}
+
+func TestGivesCheck(t *testing.T) {
+	tests := []struct {
+		fen      string
+		move     string
+		expected bool
+	}{
+		{"5k2/8/8/8/8/8/8/4K2R w K - 0 1", "e1g1", true},
+		{"3k4/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8q", true},
+		{"3k4/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8n", false},
+		{"3k4/8/8/3N4/8/8/8/3RK3 w - - 0 1", "d5f4", true},
+		{defaultFEN, "e2e4", false},
+	}
+
+	for _, test := range tests {
+		b := NewBoard(test.fen)
+		move, err := b.LegalMove(test.move)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.GivesCheck(move) != test.expected {
+			t.Errorf("Expected check %v for %s in %s\n", test.expected, test.move, test.fen)
+		}
+	}
+}
+
+func TestPinned(t *testing.T) {
+	b := NewBoard("4k3/4r3/8/b7/8/2P5/4B3/4K3 w - - 0 1")
+
+	// the pawn on c3 shields the king from the bishop on a5
+	if pinned := b.Pinned(White); !reflect.DeepEqual(pinned, []Square{E2, C3}) {
+		t.Errorf("Expected the pieces on e2 and c3 to be pinned but found %v\n", pinned)
+	}
+	if pinned := b.Pinned(Black); len(pinned) != 0 {
+		t.Errorf("Expected no pinned black pieces but found %v\n", pinned)
+	}
+	if _, err := b.LegalMove("e2d3"); err == nil {
+		t.Error("Expected the pinned bishop not to leave the file")
+	}
+	if _, err := b.LegalMove("c3c4"); err == nil {
+		t.Error("Expected the pinned pawn not to leave the diagonal")
+	}
+}
+
+// checkIsLegal compares IsLegal for all square pairs with the generated moves
+func checkIsLegal(t *testing.T, b *Board) {
+	t.Helper()
+
+	legal := map[Move]bool{}
+	for _, move := range NewGenerator(b).GenerateMoves() {
+		legal[move] = true
+	}
+
+	for from := int8(0); from < boardSize; from++ {
+		for to := int8(0); to < boardSize; to++ {
+			if !b.legalSquare(from) || !b.legalSquare(to) {
+				continue
+			}
+			for _, kind := range promotionKinds {
+				move := b.completeMove(Square(from), Square(to), kind)
+				if b.IsLegal(move) != legal[move] {
+					t.Fatalf("Expected legal %v for %+v in %s\n", legal[move], move, generateFEN(b))
+				}
+			}
+		}
+	}
+}
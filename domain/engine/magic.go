@@ -0,0 +1,177 @@
+package engine
+
+import "math/bits"
+
+// magic maps the occupancy of the relevant squares of a slider onto its attacks:
+// the masked occupancy times the magic number keeps a unique index in its top bits
+type magic struct {
+	mask    uint64
+	magic   uint64
+	shift   uint
+	attacks []uint64
+}
+
+var (
+	rookMagics   [64]magic
+	bishopMagics [64]magic
+
+	// the attacks of all squares share one table per slider
+	rookTable   [102400]uint64
+	bishopTable [5248]uint64
+)
+
+// rookMagicNumbers and bishopMagicNumbers were found by findMagic, a number that does
+// not fit the tables anymore is searched again at init
+var (
+	rookMagicNumbers = [64]uint64{
+		0x1080004008801020, 0x0840092002c03000, 0x1900200010400900, 0x0880100008000480,
+		0x4200100420080200, 0x8100020100080400, 0x0200040110886200, 0x0200008040220411,
+		0x0404800084400220, 0x0000401000402000, 0x0086001081220440, 0x0408800800100280,
+		0x000a001201040820, 0x8848800200840080, 0x4001000100040200, 0x0442000102105084,
+		0x9080010020804100, 0x0040404000201009, 0x0000808010002009, 0x2200090021d00100,
+		0x0008008008040080, 0x0004004002010040, 0x0011040008015042, 0x00000a0001768104,
+		0x0000800080204009, 0x2010004140002001, 0x9800200280100080, 0x1000100080080080,
+		0x0442000a00049020, 0x2100040080020080, 0x0800120400900148, 0x0010040a00128541,
+		0x2800804000800030, 0x1010002000400041, 0x4000200011004100, 0x0610008410800800,
+		0x0400802402800800, 0xc100020080800400, 0x0002000802000401, 0x0182085882000401,
+		0x0220204000808000, 0x2860100040024022, 0x0001002004110040, 0x99101042000a0020,
+		0x0004080004008080, 0x0010040002008080, 0x2012004881020004, 0x8300842444820011,
+		0x0088403882010200, 0x0820400080210100, 0x0110910040a00300, 0x0801100280080480,
+		0x0242009008200600, 0x1002000489500200, 0x0040800200010080, 0x0091800041000080,
+		0x0000209300488001, 0x04c1002414824001, 0x020020000b001041, 0x7000100004200901,
+		0x8002002004100802, 0x30010002084c0007, 0x0888221800813004, 0x4000002840840112,
+	}
+	bishopMagicNumbers = [64]uint64{
+		0x10102002004a1420, 0x8020040400584008, 0x10510800811201c8, 0x5204042080000088,
+		0x2204106880000002, 0x1401042004000000, 0x0400880410042004, 0x0028208200a02020,
+		0x1500241990010e00, 0x8001200182020a40, 0x40004101030b0000, 0x8002041042000100,
+		0x4010011041020038, 0x0000010421044000, 0x1500210808020a00, 0x8000088400880520,
+		0x0405004010040100, 0x1005823210040108, 0x2708008102040011, 0x4048200404009100,
+		0x0018104101400024, 0x0003000601190101, 0x8004803108491000, 0x8014241200820800,
+		0x0006e080100c3040, 0x0501044a11041800, 0x9020300008004045, 0x0894080000220040,
+		0x1001010083104000, 0x5004030040900080, 0x000400422c012400, 0x0002128698404812,
+		0x1010108404900440, 0x0928021182084100, 0x2006080409020024, 0x1010202020180080,
+		0xa010008200202200, 0x2098015100019004, 0x0002041440810811, 0x802a02020000b098,
+		0x0009015090004060, 0x4000821082081001, 0x0100210040420800, 0x0800004010488a00,
+		0x2000081104004040, 0x4c8e029015000082, 0x0420340322224842, 0x1298260043400210,
+		0x0000822802400008, 0x00008a0101600000, 0x3040003412080021, 0x3040290220884800,
+		0x4a1500401041004a, 0x8010200282020781, 0x0020203142209091, 0x0070300600902110,
+		0x0040808800b62048, 0x0000810400c44420, 0x00080400440c0441, 0x8340080020840411,
+		0x0000000104208200, 0x0000800810d00080, 0x0400530411080200, 0x4040702400932244,
+	}
+)
+
+func (m *magic) index(occupied uint64) uint64 {
+	return (occupied & m.mask * m.magic) >> m.shift
+}
+
+func rookAttacks(sq int, occupied uint64) uint64 {
+	m := &rookMagics[sq]
+	return m.attacks[m.index(occupied)]
+}
+
+func bishopAttacks(sq int, occupied uint64) uint64 {
+	m := &bishopMagics[sq]
+	return m.attacks[m.index(occupied)]
+}
+
+// relevantSquares returns the squares whose occupancy can block the rays of the
+// directions, the last square of a ray never blocks anything behind it
+func relevantSquares(sq int, directions []int) uint64 {
+	mask := uint64(0)
+	for _, direction := range directions {
+		ray := rays[direction][sq]
+		if ray == 0 {
+			continue
+		}
+		last := 63 - bits.LeadingZeros64(ray)
+		if direction == raySouth || direction == rayWest || direction == raySouthEast || direction == raySouthWest {
+			last = bits.TrailingZeros64(ray)
+		}
+		mask |= ray &^ (uint64(1) << last)
+	}
+	return mask
+}
+
+// initMagics fills the attack table of a slider from the ray walks, using the given
+// magic numbers
+func initMagics(magics *[64]magic, table []uint64, directions []int, numbers *[64]uint64) {
+	offset := 0
+	occupancies := make([]uint64, 1<<12)
+	references := make([]uint64, 1<<12)
+	epochs := make([]int, 1<<12)
+
+	for sq := range magics {
+		m := &magics[sq]
+		m.mask = relevantSquares(sq, directions)
+		count := bits.OnesCount64(m.mask)
+		m.shift = uint(64 - count)
+		m.attacks = table[offset : offset+1<<count]
+		offset += 1 << count
+
+		// enumerate all subsets of the mask
+		size := 0
+		for subset := uint64(0); ; {
+			occupancies[size] = subset
+			references[size] = slidingAttacks(sq, subset, directions)
+			size++
+			if subset = (subset - m.mask) & m.mask; subset == 0 {
+				break
+			}
+		}
+
+		m.magic = numbers[sq]
+		if !m.fill(occupancies[:size], references[:size], epochs, 1) {
+			m.findMagic(occupancies[:size], references[:size], epochs)
+		}
+		// the epochs restart for the next square
+		for i := range epochs {
+			epochs[i] = 0
+		}
+	}
+}
+
+// fill stores the attacks at the indices of the occupancies, it fails if two
+// occupancies with different attacks share an index. Indices written in an earlier
+// epoch count as free.
+func (m *magic) fill(occupancies, references []uint64, epochs []int, epoch int) bool {
+	for i, occupied := range occupancies {
+		index := m.index(occupied)
+		if epochs[index] != epoch {
+			epochs[index] = epoch
+			m.attacks[index] = references[i]
+		} else if m.attacks[index] != references[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// findMagic tries random numbers with a fixed seed until one fills the table
+func (m *magic) findMagic(occupancies, references []uint64, epochs []int) {
+	random := magicRandom(0x9e3779b97f4a7c15)
+	for epoch := 2; ; epoch++ {
+		m.magic = random.sparse()
+		if bits.OnesCount64(m.mask*m.magic>>56) < 6 {
+			continue
+		}
+		if m.fill(occupancies, references, epochs, epoch) {
+			return
+		}
+	}
+}
+
+// magicRandom is a xorshift generator, its fixed seed makes the tables reproducible
+type magicRandom uint64
+
+func (r *magicRandom) next() uint64 {
+	*r ^= *r >> 12
+	*r ^= *r << 25
+	*r ^= *r >> 27
+	return uint64(*r) * 0x2545f4914f6cdd1d
+}
+
+// sparse returns a number with few bits set, which make good magic candidates
+func (r *magicRandom) sparse() uint64 {
+	return r.next() & r.next() & r.next()
+}
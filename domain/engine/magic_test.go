@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMagicAttacksMatchRayWalks(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for sq := 0; sq < 64; sq++ {
+		for i := 0; i < 200; i++ {
+			// sparse and dense occupancies
+			occupied := random.Uint64() & random.Uint64()
+			if i%2 == 0 {
+				occupied |= random.Uint64()
+			}
+
+			if rookAttacks(sq, occupied) != slidingAttacks(sq, occupied, rookDirections) {
+				t.Fatalf("Expected the rook attacks of square %d to match the ray walk for %x\n", sq, occupied)
+			}
+			if bishopAttacks(sq, occupied) != slidingAttacks(sq, occupied, bishopDirections) {
+				t.Fatalf("Expected the bishop attacks of square %d to match the ray walk for %x\n", sq, occupied)
+			}
+		}
+	}
+}
+
+func TestMagicNumbersFitTables(t *testing.T) {
+	for sq := 0; sq < 64; sq++ {
+		if rookMagics[sq].magic != rookMagicNumbers[sq] || bishopMagics[sq].magic != bishopMagicNumbers[sq] {
+			t.Errorf("Expected the magic numbers of square %d to be kept\n", sq)
+		}
+	}
+}
+
+func BenchmarkRookAttacks(b *testing.B) {
+	occupied := NewBoard(position2FEN).bitboards.occupied()
+	for i := 0; i < b.N; i++ {
+		rookAttacks(i&63, occupied)
+	}
+}
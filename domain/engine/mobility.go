@@ -0,0 +1,60 @@
+package engine
+
+var (
+	// average number of reachable squares, indexed by piece
+	mobilityCenter = []int{0, 0, 4, 6, 7, 13}
+
+	pieceDeltas  = [][]int8{nil, nil, deltaKnight, deltaBishop, deltaRook, deltaQueen}
+	pieceSliding = []bool{false, false, false, true, true, true}
+)
+
+// pieceActivity counts the squares a piece reaches that are neither occupied by its own
+// pieces nor attacked by enemy pawns, and the squares it attacks next to the enemy king
+func pieceActivity(b *Board, sq int8, enemyKing int8) (int, int) {
+	piece := b.data[sq]
+	kind := abs(piece)
+	mobility, zone := 0, 0
+
+	for _, delta := range pieceDeltas[kind] {
+		for target := sq + delta; b.legalSquare(target); target += delta {
+			content := b.data[target]
+
+			if distance(target, enemyKing) <= 1 {
+				zone++
+			}
+			if content*piece <= 0 && !attackedByPawn(b, target, -piece) {
+				mobility++
+			}
+
+			if content != Empty || !pieceSliding[kind] {
+				break
+			}
+		}
+	}
+
+	return mobility, zone
+}
+
+// attackedByPawn checks whether a pawn of the color of the given piece attacks the square
+func attackedByPawn(b *Board, sq int8, piece int8) bool {
+	pawn := WhitePawn
+	if piece < 0 {
+		pawn = BlackPawn
+	}
+
+	// pawns attack forward, so look for them one rank back from their side
+	from := sq - pawn*nextRank
+	for _, attacker := range []int8{from - 1, from + 1} {
+		if b.legalSquare(attacker) && b.data[attacker] == pawn {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateMobility scores the mobility of a piece for the midgame and the endgame
+func evaluateMobility(kind int8, mobility int, p *EvalParams) (int, int) {
+	delta := mobility - mobilityCenter[kind]
+	weight := p.mobility(kind)
+	return delta * weight.Middle, delta * weight.End
+}
@@ -0,0 +1,48 @@
+package engine
+
+import "testing"
+
+func TestPieceActivityCountsReachableSquares(t *testing.T) {
+	b, _ := parseFEN("7k/8/8/8/8/8/8/R6K w - - 0 1")
+
+	if mobility, _ := pieceActivity(b, int8(A1), int8(H8)); mobility != 13 {
+		t.Errorf("Expected the rook to reach 13 squares but found %d\n", mobility)
+	}
+}
+
+func TestPieceActivityAvoidsSquaresAttackedByPawns(t *testing.T) {
+	b, _ := parseFEN("7k/8/2p5/8/8/2N5/8/7K w - - 0 1")
+
+	// b5 and d5 are controlled by the pawn on c6
+	if mobility, _ := pieceActivity(b, int8(C3), int8(H8)); mobility != 6 {
+		t.Errorf("Expected the knight to reach 6 squares but found %d\n", mobility)
+	}
+}
+
+func TestPieceActivityCountsKingZone(t *testing.T) {
+	b, _ := parseFEN("6k1/5ppp/8/8/8/8/8/Q6K w - - 0 1")
+
+	// the queen reaches g7 on the diagonal a1-h8, which is next to the king
+	if _, zone := pieceActivity(b, int8(A1), int8(G8)); zone != 1 {
+		t.Errorf("Expected one attacked square next to the king but found %d\n", zone)
+	}
+}
+
+func TestKingAttackNeedsTwoAttackers(t *testing.T) {
+	if penalty := kingAttackPenalty(10, 1, &defaultEvalParams); penalty != 0 {
+		t.Errorf("Expected no penalty for a single attacker but found %d\n", penalty)
+	}
+	if penalty := kingAttackPenalty(10, 2, &defaultEvalParams); penalty >= 0 {
+		t.Errorf("Expected a penalty for two attackers but found %d\n", penalty)
+	}
+}
+
+func TestKingSafetyNeedsEnemyQueen(t *testing.T) {
+	b, _ := parseFEN("6k1/5ppp/8/8/8/8/8/3Q2K1 w - - 0 1")
+	terms, _ := EvaluateBreakdown(b)
+
+	// only Black faces a queen, so only its shelter is counted
+	if shelter := findTerm(terms, "PawnShelter"); shelter == nil || shelter.Middle[0] != 0 || shelter.Middle[1] <= 0 {
+		t.Errorf("Expected the shelter of Black only but found %+v\n", shelter)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 const (
@@ -18,6 +19,8 @@ const (
 	castleShort int8 = 2
 )
 
+var promotionPieces = map[byte]int8{'q': Queen, 'r': Rook, 'b': Bishop, 'n': Knight}
+
 // Move on the board representation
 type Move struct {
 	From       Square
@@ -28,6 +31,37 @@ type Move struct {
 	Promoted   int8
 }
 
+// PackedMove stores a move in 16 bits: the from square in bits 0-5, the to square in
+// bits 6-11 and the special kind in bits 12-15, promotions from Knight to Queen above 7.
+// The pieces come from the board the move is unpacked on. The zero value is no move.
+type PackedMove uint16
+
+const packedPromotion = 8
+
+// Pack stores the move in 16 bits
+func (m Move) Pack() PackedMove {
+	flags := uint16(m.Special)
+	if m.Special == movePromotion {
+		flags = packedPromotion + uint16(abs(m.Promoted)-Knight)
+	}
+	return PackedMove(uint16(to64(int8(m.From))) | uint16(to64(int8(m.To)))<<6 | flags<<12)
+}
+
+// UnpackMove restores a packed move for the position it was played in
+func (b *Board) UnpackMove(p PackedMove) Move {
+	from, to, flags := to0x88(int(p&63)), to0x88(int(p>>6&63)), int8(p>>12)
+	m := Move{From: Square(from), To: Square(to), Special: flags, MovedPiece: b.data[from], Content: b.data[to], Promoted: Empty}
+
+	switch {
+	case flags >= packedPromotion:
+		m.Special = movePromotion
+		m.Promoted = m.MovedPiece * (Knight + flags - packedPromotion)
+	case flags == moveEnPassant:
+		m.Content = -m.MovedPiece
+	}
+	return m
+}
+
 func (m Move) String() string {
 
 	if m.Special == moveCastelingLong {
@@ -48,18 +82,37 @@ func (m Move) String() string {
 	return str
 }
 
+// UciString formats the move in the long algebraic notation of the UCI protocol
+func (m Move) UciString() string {
+	str := SquareMap[m.From] + SquareMap[m.To]
+
+	if m.Special == movePromotion {
+		str += strings.ToLower(pieceString(abs(m.Promoted)))
+	}
+
+	return str
+}
+
 func CreateMove(str string) (Move, error) {
 
-	// TODO castling/promotion
+	// TODO castling
 
-	if m, _ := regexp.MatchString("^[a-h][1-8][a-h][1-8]$", str); !m {
+	if m, _ := regexp.MatchString("^[a-h][1-8][a-h][1-8][qrbn]?$", str); !m {
 		return Move{}, errors.New("invalid move")
 	}
 
 	from := str[:2]
-	to := str[2:]
+	to := str[2:4]
+
+	move := Move{From: SquareLookup[from], To: SquareLookup[to]}
+
+	// promotions carry the uncolored piece
+	if len(str) == 5 {
+		move.Special = movePromotion
+		move.Promoted = promotionPieces[str[4]]
+	}
 
-	return Move{From: SquareLookup[from], To: SquareLookup[to]}, nil
+	return move, nil
 }
 
 func printMoves(moves []Move) {
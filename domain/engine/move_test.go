@@ -0,0 +1,41 @@
+package engine
+
+import "testing"
+
+func TestPackedMoveRoundTrip(t *testing.T) {
+	fens := []string{
+		position2FEN,
+		"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+		"8/8/8/K2pP2r/8/8/8/7k w - d6 0 1",
+		"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1",
+	}
+
+	for _, fen := range fens {
+		b := NewBoard(fen)
+		for _, move := range NewGenerator(b).GenerateMoves() {
+			b.MakeMove(move)
+			for _, reply := range NewGenerator(b).GenerateMoves() {
+				if unpacked := b.UnpackMove(reply.Pack()); unpacked != reply {
+					t.Errorf("Expected %+v but unpacked %+v in %s\n", reply, unpacked, generateFEN(b))
+				}
+			}
+			b.UndoMove()
+
+			if unpacked := b.UnpackMove(move.Pack()); unpacked != move {
+				t.Errorf("Expected %+v but unpacked %+v in %s\n", move, unpacked, fen)
+			}
+		}
+	}
+}
+
+func TestPackedMovesDiffer(t *testing.T) {
+	b := NewBoard("4k3/1P6/8/8/8/8/8/4K3 w - - 0 1")
+
+	seen := map[PackedMove]bool{0: true}
+	for _, move := range NewGenerator(b).GenerateMoves() {
+		if seen[move.Pack()] {
+			t.Errorf("Expected %s to pack uniquely\n", move.UciString())
+		}
+		seen[move.Pack()] = true
+	}
+}
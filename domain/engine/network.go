@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	// one input for every piece of both colors on every square
+	networkInputs    = 2 * int(King) * 64
+	networkMaxHidden = 4096
+
+	// quantisation of the hidden layer and the output weights
+	networkQA    = 255
+	networkQB    = 64
+	networkScale = 400
+)
+
+var networkMagic = [4]byte{'G', 'C', 'N', 'N'}
+
+// Network is an efficiently updatable neural network with 768 inputs, one hidden layer
+// for each side and a single output. It scores the board for the side to move.
+type Network struct {
+	hidden         int
+	featureWeights []int16
+	featureBias    []int16
+	outputWeights  []int16
+	outputBias     int32
+}
+
+// accumulator holds the hidden layer of White and Black, seen from their side of the board
+type accumulator [2][]int16
+
+// networkState keeps the accumulators of the played moves of a board
+type networkState struct {
+	net   *Network
+	stack []accumulator
+	top   int
+}
+
+// LoadNetwork reads the weights of a network from a file. The file starts with the
+// magic "GCNN" and the size of the hidden layer, followed by the feature weights, the
+// feature biases, the output weights and the output bias, all little endian.
+func LoadNetwork(path string) (*Network, error) {
+	input, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	n, err := readNetwork(bufio.NewReader(input))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return n, nil
+}
+
+func readNetwork(r io.Reader) (*Network, error) {
+	var magic [4]byte
+	var hidden uint32
+
+	if err := binary.Read(r, binary.LittleEndian, &magic); err != nil || magic != networkMagic {
+		return nil, errors.New("not a network file")
+	}
+	if err := binary.Read(r, binary.LittleEndian, &hidden); err != nil {
+		return nil, err
+	}
+	if hidden == 0 || hidden > networkMaxHidden {
+		return nil, fmt.Errorf("invalid hidden size: %d", hidden)
+	}
+
+	n := newNetwork(int(hidden))
+	for _, values := range []interface{}{n.featureWeights, n.featureBias, n.outputWeights, &n.outputBias} {
+		if err := binary.Read(r, binary.LittleEndian, values); err != nil {
+			return nil, fmt.Errorf("truncated network: %s", err)
+		}
+	}
+
+	// trailing data means the file has another layout
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		return nil, errors.New("unexpected data after the network")
+	}
+
+	return n, nil
+}
+
+// WriteNetwork writes the weights of a network to a file that LoadNetwork reads
+func WriteNetwork(path string, n *Network) error {
+	output, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	w := bufio.NewWriter(output)
+	for _, values := range []interface{}{networkMagic, uint32(n.hidden), n.featureWeights, n.featureBias, n.outputWeights, n.outputBias} {
+		if err := binary.Write(w, binary.LittleEndian, values); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func newNetwork(hidden int) *Network {
+	return &Network{
+		hidden:         hidden,
+		featureWeights: make([]int16, networkInputs*hidden),
+		featureBias:    make([]int16, hidden),
+		outputWeights:  make([]int16, 2*hidden),
+	}
+}
+
+// networkFeature returns the input of a piece on a square seen from the given side,
+// where the pieces of the side come first and black sees the board mirrored
+func networkFeature(piece int8, sq int8, side int) int {
+	color := 0
+	if piece < 0 {
+		color = 1
+	}
+	index := int(rank(sq))*int(size) + int(file(sq))
+	if side == 1 {
+		color, index = 1-color, index^56
+	}
+	return (color*int(King)+int(abs(piece))-1)*64 + index
+}
+
+func (n *Network) newAccumulator() accumulator {
+	return accumulator{make([]int16, n.hidden), make([]int16, n.hidden)}
+}
+
+// refresh computes the accumulator from all pieces of the board
+func (n *Network) refresh(b *Board, acc accumulator) {
+	copy(acc[0], n.featureBias)
+	copy(acc[1], n.featureBias)
+
+	for sq := int8(0); sq < boardSize; sq++ {
+		if b.legalSquare(sq) && b.data[sq] != Empty {
+			n.update(acc, b.data[sq], sq, 1)
+		}
+	}
+}
+
+// update adds or removes a piece on a square from the accumulator
+func (n *Network) update(acc accumulator, piece int8, sq int8, sign int16) {
+	for side := range acc {
+		weights := n.featureWeights[networkFeature(piece, sq, side)*n.hidden:]
+		values := acc[side]
+		for i := range values {
+			values[i] += sign * weights[i]
+		}
+	}
+}
+
+// output scores the accumulator for the side to move
+func (n *Network) output(acc accumulator, sideToMove int8) int {
+	us, them := acc[0], acc[1]
+	if sideToMove == Black {
+		us, them = them, us
+	}
+
+	sum := int64(0)
+	for i := 0; i < n.hidden; i++ {
+		sum += int64(clippedReLU(us[i])) * int64(n.outputWeights[i])
+		sum += int64(clippedReLU(them[i])) * int64(n.outputWeights[n.hidden+i])
+	}
+
+	return int((sum + int64(n.outputBias)) * networkScale / (networkQA * networkQB))
+}
+
+func clippedReLU(v int16) int16 {
+	if v < 0 {
+		return 0
+	}
+	if v > networkQA {
+		return networkQA
+	}
+	return v
+}
+
+// Evaluate scores the board with the accumulators updated by its moves, or from scratch if
+// the network is not attached to the board
+func (n *Network) Evaluate(b *Board) int {
+	if b.network != nil && b.network.net == n {
+		return n.output(b.network.stack[b.network.top], b.sideToMove)
+	}
+
+	acc := n.newAccumulator()
+	n.refresh(b, acc)
+	return n.output(acc, b.sideToMove)
+}
+
+// attachNetwork keeps the accumulators of the network up to date with the moves of the board
+func (b *Board) attachNetwork(n *Network) {
+	b.network = &networkState{net: n, stack: []accumulator{n.newAccumulator()}}
+	n.refresh(b, b.network.stack[0])
+}
+
+// clone copies the accumulators up to the current one
+func (s *networkState) clone() *networkState {
+	c := &networkState{net: s.net, stack: make([]accumulator, s.top+1), top: s.top}
+	for i := range c.stack {
+		c.stack[i] = s.net.newAccumulator()
+		copy(c.stack[i][0], s.stack[i][0])
+		copy(c.stack[i][1], s.stack[i][1])
+	}
+	return c
+}
+
+// push updates a copy of the current accumulator by the pieces a move removed and placed
+func (s *networkState) push(m Move) {
+	s.top++
+	if s.top == len(s.stack) {
+		s.stack = append(s.stack, s.net.newAccumulator())
+	}
+	acc := s.stack[s.top]
+	copy(acc[0], s.stack[s.top-1][0])
+	copy(acc[1], s.stack[s.top-1][1])
+
+	forEachChange(m, func(piece int8, sq int8, sign int) {
+		s.net.update(acc, piece, sq, int16(sign))
+	})
+}
+
+// pop returns to the accumulator before the last move
+func (s *networkState) pop() {
+	if s.top > 0 {
+		s.top--
+	}
+}
@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNetworkAccumulatorFollowsMoves(t *testing.T) {
+	n := randomNetwork(16, 4711)
+	fens := []string{
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+		"8/8/8/K2pP2r/8/8/8/7k w - d6 0 1",
+	}
+
+	for _, fen := range fens {
+		b := NewBoard(fen)
+		b.attachNetwork(n)
+
+		for _, move := range NewGenerator(b).GenerateMoves() {
+			b.MakeMove(move)
+			for _, reply := range NewGenerator(b).GenerateMoves() {
+				b.MakeMove(reply)
+				checkAccumulator(t, n, b, reply)
+				b.UndoMove()
+			}
+			checkAccumulator(t, n, b, move)
+			b.UndoMove()
+		}
+		checkAccumulator(t, n, b, Move{})
+	}
+}
+
+func TestNetworkFile(t *testing.T) {
+	n := randomNetwork(8, 42)
+	path := filepath.Join(t.TempDir(), "net.gcnn")
+
+	if err := WriteNetwork(path, n); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadNetwork(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, n) {
+		t.Error("Expected the network to read back unchanged")
+	}
+
+	data, _ := os.ReadFile(path)
+	for _, broken := range [][]byte{data[:len(data)-1], append(data, 0), []byte("NNUE")} {
+		os.WriteFile(path, broken, 0644)
+		if _, err := LoadNetwork(path); err == nil {
+			t.Errorf("Expected a file of %d bytes to be rejected\n", len(broken))
+		}
+	}
+}
+
+func TestNetworkIsSymmetric(t *testing.T) {
+	n := randomNetwork(16, 7)
+	white := NewBoard("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3")
+	black := NewBoard("rnbqkb1r/pppp1ppp/5n2/4p3/4P3/2N5/PPPP1PPP/R1BQKBNR b KQkq - 2 3")
+
+	if n.Evaluate(white) != n.Evaluate(black) {
+		t.Errorf("Expected mirrored positions to score alike but found %d and %d\n", n.Evaluate(white), n.Evaluate(black))
+	}
+}
+
+func TestSearchWithNetwork(t *testing.T) {
+	b := NewBoard("4k3/8/8/8/8/8/3q4/3QK3 w - - 0 1")
+	lines := SearchLines(b, SearchOptions{MultiPV: 1, Network: randomNetwork(8, 1), Mate: 1})
+
+	if len(lines) == 0 || lines[0].Move.To != D2 {
+		t.Errorf("Expected the queen to be taken with a network evaluation but found %v\n", lines)
+	}
+}
+
+func checkAccumulator(t *testing.T, n *Network, b *Board, m Move) {
+	t.Helper()
+
+	expected := n.newAccumulator()
+	n.refresh(b, expected)
+	if !reflect.DeepEqual(b.network.stack[b.network.top], expected) {
+		t.Fatalf("Expected the accumulator to match the board after %s\n", m.UciString())
+	}
+}
+
+func randomNetwork(hidden int, seed int64) *Network {
+	random := rand.New(rand.NewSource(seed))
+	n := newNetwork(hidden)
+
+	for i := range n.featureWeights {
+		n.featureWeights[i] = int16(random.Intn(65) - 32)
+	}
+	for i := range n.featureBias {
+		n.featureBias[i] = int16(random.Intn(129))
+	}
+	for i := range n.outputWeights {
+		n.outputWeights[i] = int16(random.Intn(129) - 64)
+	}
+	n.outputBias = int32(random.Intn(1000))
+
+	return n
+}
@@ -0,0 +1,340 @@
+package engine
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// evalTableSize is the size of a piece square table, eight ranks of the 0x88 board
+const evalTableSize = 8 * 16
+
+// EvalWeight is a tunable weight of an evaluation term for the midgame and the endgame
+type EvalWeight struct {
+	Middle int `json:"middle" yaml:"middle"`
+	End    int `json:"end" yaml:"end"`
+}
+
+// EvalList is a tunable list of weights indexed by a rank, a distance, a count or a piece
+type EvalList []int
+
+// EvalParams holds the named weights of the evaluation terms
+type EvalParams struct {
+	PawnValue   EvalWeight `json:"pawnValue" yaml:"pawnValue"`
+	KnightValue EvalWeight `json:"knightValue" yaml:"knightValue"`
+	BishopValue EvalWeight `json:"bishopValue" yaml:"bishopValue"`
+	RookValue   EvalWeight `json:"rookValue" yaml:"rookValue"`
+	QueenValue  EvalWeight `json:"queenValue" yaml:"queenValue"`
+
+	DoubledPawn   EvalWeight `json:"doubledPawn" yaml:"doubledPawn"`
+	IsolatedPawn  EvalWeight `json:"isolatedPawn" yaml:"isolatedPawn"`
+	BackwardPawn  EvalWeight `json:"backwardPawn" yaml:"backwardPawn"`
+	ConnectedPawn EvalWeight `json:"connectedPawn" yaml:"connectedPawn"`
+	PawnIsland    EvalWeight `json:"pawnIsland" yaml:"pawnIsland"`
+
+	// mobility per reachable square beyond the average of the piece
+	KnightMobility EvalWeight `json:"knightMobility" yaml:"knightMobility"`
+	BishopMobility EvalWeight `json:"bishopMobility" yaml:"bishopMobility"`
+	RookMobility   EvalWeight `json:"rookMobility" yaml:"rookMobility"`
+	QueenMobility  EvalWeight `json:"queenMobility" yaml:"queenMobility"`
+
+	BishopPair       EvalWeight `json:"bishopPair" yaml:"bishopPair"`
+	RookOpenFile     EvalWeight `json:"rookOpenFile" yaml:"rookOpenFile"`
+	RookSemiOpenFile EvalWeight `json:"rookSemiOpenFile" yaml:"rookSemiOpenFile"`
+	RookSeventhRank  EvalWeight `json:"rookSeventhRank" yaml:"rookSeventhRank"`
+	KnightOutpost    EvalWeight `json:"knightOutpost" yaml:"knightOutpost"`
+	BishopOutpost    EvalWeight `json:"bishopOutpost" yaml:"bishopOutpost"`
+	TrappedBishop    EvalWeight `json:"trappedBishop" yaml:"trappedBishop"`
+	TrappedRook      EvalWeight `json:"trappedRook" yaml:"trappedRook"`
+	Casteling        EvalWeight `json:"casteling" yaml:"casteling"`
+	Check            EvalWeight `json:"check" yaml:"check"`
+
+	// the check bonus applies once a side has no more material than this
+	MateSearchLevel int `json:"mateSearchLevel" yaml:"mateSearchLevel" tune:"-"`
+
+	// passed pawn bonus by the rank relative to the side of the pawn
+	PassedPawnMiddle EvalList `json:"passedPawnMiddle" yaml:"passedPawnMiddle"`
+	PassedPawnEnd    EvalList `json:"passedPawnEnd" yaml:"passedPawnEnd"`
+	// a blocked passed pawn keeps only a part of its bonus
+	PassedPawnBlockedDivisor int `json:"passedPawnBlockedDivisor" yaml:"passedPawnBlockedDivisor" tune:"-"`
+	// endgame bonus per square of king distance to the square in front of a passed pawn
+	PassedPawnEnemyKing int `json:"passedPawnEnemyKing" yaml:"passedPawnEnemyKing"`
+	PassedPawnOwnKing   int `json:"passedPawnOwnKing" yaml:"passedPawnOwnKing"`
+
+	// attack units of a piece per attacked square next to the enemy king, indexed by piece
+	KingAttackUnits EvalList `json:"kingAttackUnits" yaml:"kingAttackUnits"`
+	// king safety penalty by the attack units of the opponent
+	KingAttackTable EvalList `json:"kingAttackTable" yaml:"kingAttackTable"`
+	// the number of attackers below which the king is safe
+	KingAttackersMin int `json:"kingAttackersMin" yaml:"kingAttackersMin" tune:"-"`
+	// pawns in front of the king by the distance of the closest one, zero for none
+	PawnShield EvalList `json:"pawnShield" yaml:"pawnShield"`
+	PawnStorm  EvalList `json:"pawnStorm" yaml:"pawnStorm"`
+
+	// a known endgame win scores above any material advantage but below a mate
+	KnownWin     int `json:"knownWin" yaml:"knownWin" tune:"-"`
+	PushToEdge   int `json:"pushToEdge" yaml:"pushToEdge"`
+	PushToCorner int `json:"pushToCorner" yaml:"pushToCorner"`
+	PushClose    int `json:"pushClose" yaml:"pushClose"`
+	PushPawn     int `json:"pushPawn" yaml:"pushPawn"`
+
+	// the endgame score of drawish material is scaled by the factor out of scaleNormal
+	ScaleOppositeBishops       int `json:"scaleOppositeBishops" yaml:"scaleOppositeBishops"`
+	ScaleOppositeBishopsPieces int `json:"scaleOppositeBishopsPieces" yaml:"scaleOppositeBishopsPieces"`
+	ScaleNoPawnsMinor          int `json:"scaleNoPawnsMinor" yaml:"scaleNoPawnsMinor"`
+	ScaleNoPawns               int `json:"scaleNoPawns" yaml:"scaleNoPawns"`
+
+	// piece square tables in the 0x88 layout seen from white
+	PawnTableMiddle   []int `json:"pawnTableMiddle" yaml:"pawnTableMiddle"`
+	KnightTableMiddle []int `json:"knightTableMiddle" yaml:"knightTableMiddle"`
+	BishopTableMiddle []int `json:"bishopTableMiddle" yaml:"bishopTableMiddle"`
+	RookTableMiddle   []int `json:"rookTableMiddle" yaml:"rookTableMiddle"`
+	QueenTableMiddle  []int `json:"queenTableMiddle" yaml:"queenTableMiddle"`
+	KingTableMiddle   []int `json:"kingTableMiddle" yaml:"kingTableMiddle"`
+	PawnTableEnd      []int `json:"pawnTableEnd" yaml:"pawnTableEnd"`
+	KnightTableEnd    []int `json:"knightTableEnd" yaml:"knightTableEnd"`
+	BishopTableEnd    []int `json:"bishopTableEnd" yaml:"bishopTableEnd"`
+	RookTableEnd      []int `json:"rookTableEnd" yaml:"rookTableEnd"`
+	QueenTableEnd     []int `json:"queenTableEnd" yaml:"queenTableEnd"`
+	KingTableEnd      []int `json:"kingTableEnd" yaml:"kingTableEnd"`
+}
+
+var defaultEvalParams = EvalParams{
+	PawnValue:   EvalWeight{pawnValue, pawnValueEnd},
+	KnightValue: EvalWeight{knightValue, knightValueEnd},
+	BishopValue: EvalWeight{bishopValue, bishopValueEnd},
+	RookValue:   EvalWeight{rookValue, rookValueEnd},
+	QueenValue:  EvalWeight{queenValue, queenValueEnd},
+
+	DoubledPawn:   EvalWeight{evalPenaltyDoublePawn, evalPenaltyDoublePawn},
+	IsolatedPawn:  EvalWeight{-10, -16},
+	BackwardPawn:  EvalWeight{-8, -10},
+	ConnectedPawn: EvalWeight{6, 8},
+	PawnIsland:    EvalWeight{-8, -8},
+
+	KnightMobility: EvalWeight{4, 4},
+	BishopMobility: EvalWeight{5, 5},
+	RookMobility:   EvalWeight{2, 4},
+	QueenMobility:  EvalWeight{1, 2},
+
+	BishopPair:       EvalWeight{30, 50},
+	RookOpenFile:     EvalWeight{25, 10},
+	RookSemiOpenFile: EvalWeight{12, 6},
+	RookSeventhRank:  EvalWeight{20, 30},
+	KnightOutpost:    EvalWeight{20, 10},
+	BishopOutpost:    EvalWeight{10, 5},
+	TrappedBishop:    EvalWeight{-80, -80},
+	TrappedRook:      EvalWeight{-50, 0},
+	Casteling:        EvalWeight{evalBonusCasteling, 0},
+	Check:            EvalWeight{evalBonusCheck, evalBonusCheck},
+
+	MateSearchLevel: evalMateSearchLevel,
+
+	PassedPawnMiddle:         passedPawnMiddle,
+	PassedPawnEnd:            passedPawnEnd,
+	PassedPawnBlockedDivisor: evalPassedPawnBlockedDivisor,
+	PassedPawnEnemyKing:      evalPassedPawnEnemyKing,
+	PassedPawnOwnKing:        evalPassedPawnOwnKing,
+
+	KingAttackUnits:  kingAttackUnits,
+	KingAttackTable:  kingAttackTable,
+	KingAttackersMin: evalKingAttackersMin,
+	PawnShield:       pawnShield,
+	PawnStorm:        pawnStorm,
+
+	KnownWin:     evalKnownWin,
+	PushToEdge:   evalPushToEdge,
+	PushToCorner: evalPushToCorner,
+	PushClose:    evalPushClose,
+	PushPawn:     evalPushPawn,
+
+	ScaleOppositeBishops:       scaleOppositeBishops,
+	ScaleOppositeBishopsPieces: scaleOppositeBishopsPieces,
+	ScaleNoPawnsMinor:          scaleNoPawnsMinor,
+	ScaleNoPawns:               scaleNoPawns,
+
+	PawnTableMiddle:   pawnTableMiddle,
+	KnightTableMiddle: knightTableMiddle,
+	BishopTableMiddle: bishopTableMiddle,
+	RookTableMiddle:   rookTableMiddle,
+	QueenTableMiddle:  queenTableMiddle,
+	KingTableMiddle:   kingTableMiddle,
+	PawnTableEnd:      pawnTableEnd,
+	KnightTableEnd:    knightTableEnd,
+	BishopTableEnd:    bishopTableEnd,
+	RookTableEnd:      rookTableEnd,
+	QueenTableEnd:     queenTableEnd,
+	KingTableEnd:      kingTableEnd,
+}
+
+// pieceValue returns the material value of a piece kind
+func (p *EvalParams) pieceValue(kind int8) EvalWeight {
+	switch kind {
+	case Pawn:
+		return p.PawnValue
+	case Knight:
+		return p.KnightValue
+	case Bishop:
+		return p.BishopValue
+	case Rook:
+		return p.RookValue
+	case Queen:
+		return p.QueenValue
+	}
+	return EvalWeight{}
+}
+
+// mobility returns the weight of the mobility of a piece kind
+func (p *EvalParams) mobility(kind int8) EvalWeight {
+	switch kind {
+	case Knight:
+		return p.KnightMobility
+	case Bishop:
+		return p.BishopMobility
+	case Rook:
+		return p.RookMobility
+	case Queen:
+		return p.QueenMobility
+	}
+	return EvalWeight{}
+}
+
+// tables returns the midgame and endgame piece square tables of a piece kind
+func (p *EvalParams) tables(kind int8) ([]int, []int) {
+	switch kind {
+	case Pawn:
+		return p.PawnTableMiddle, p.PawnTableEnd
+	case Knight:
+		return p.KnightTableMiddle, p.KnightTableEnd
+	case Bishop:
+		return p.BishopTableMiddle, p.BishopTableEnd
+	case Rook:
+		return p.RookTableMiddle, p.RookTableEnd
+	case Queen:
+		return p.QueenTableMiddle, p.QueenTableEnd
+	case King:
+		return p.KingTableMiddle, p.KingTableEnd
+	}
+	return nil, nil
+}
+
+// clone copies the parameters with their own tables and lists
+func (p *EvalParams) clone() EvalParams {
+	c := *p
+	v := reflect.ValueOf(&c).Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		switch values := v.Field(i).Interface().(type) {
+		case []int:
+			v.Field(i).Set(reflect.ValueOf(append([]int(nil), values...)))
+		case EvalList:
+			v.Field(i).Set(reflect.ValueOf(append(EvalList(nil), values...)))
+		}
+	}
+
+	return c
+}
+
+// validate checks the piece values, the factors and the sizes of the lists and that every
+// table covers the 0x88 board with empty entries off the board
+func (p *EvalParams) validate() error {
+	for kind := Pawn; kind <= Queen; kind++ {
+		if value := p.pieceValue(kind); value.Middle <= 0 || value.End <= 0 {
+			return fmt.Errorf("invalid value of %s: %d, %d", symbols[kind], value.Middle, value.End)
+		}
+	}
+	if p.MateSearchLevel < 0 {
+		return fmt.Errorf("invalid mateSearchLevel: %d", p.MateSearchLevel)
+	}
+	if p.PassedPawnBlockedDivisor <= 0 {
+		return fmt.Errorf("invalid passedPawnBlockedDivisor: %d", p.PassedPawnBlockedDivisor)
+	}
+	if p.KingAttackersMin < 0 {
+		return fmt.Errorf("invalid kingAttackersMin: %d", p.KingAttackersMin)
+	}
+	if p.KnownWin <= 0 || p.KnownWin >= scoreMate {
+		return fmt.Errorf("invalid knownWin: %d", p.KnownWin)
+	}
+	for _, scale := range []struct {
+		name  string
+		value int
+	}{
+		{"scaleOppositeBishops", p.ScaleOppositeBishops},
+		{"scaleOppositeBishopsPieces", p.ScaleOppositeBishopsPieces},
+		{"scaleNoPawnsMinor", p.ScaleNoPawnsMinor},
+		{"scaleNoPawns", p.ScaleNoPawns},
+	} {
+		if scale.value < scaleDraw || scale.value > scaleNormal {
+			return fmt.Errorf("invalid %s: %d", scale.name, scale.value)
+		}
+	}
+
+	// the lists are indexed by a rank, a distance or a piece, the attack table by any count
+	for _, list := range []struct {
+		name   string
+		values EvalList
+		length int
+	}{
+		{"passedPawnMiddle", p.PassedPawnMiddle, int(size)},
+		{"passedPawnEnd", p.PassedPawnEnd, int(size)},
+		{"kingAttackUnits", p.KingAttackUnits, int(Queen) + 1},
+		{"kingAttackTable", p.KingAttackTable, len(p.KingAttackTable)},
+		{"pawnShield", p.PawnShield, int(size)},
+		{"pawnStorm", p.PawnStorm, int(size)},
+	} {
+		if len(list.values) == 0 || len(list.values) != list.length {
+			return fmt.Errorf("invalid size of %s: %d", list.name, len(list.values))
+		}
+	}
+
+	v := reflect.ValueOf(p).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		table, ok := v.Field(i).Interface().([]int)
+		if !ok {
+			continue
+		}
+		name := v.Type().Field(i).Tag.Get("json")
+
+		if len(table) != evalTableSize {
+			return fmt.Errorf("invalid size of %s: %d instead of %d", name, len(table), evalTableSize)
+		}
+		for sq, value := range table {
+			if sq&0x88 != 0 && value != 0 {
+				return fmt.Errorf("invalid %s: entry %d is off the board", name, sq)
+			}
+		}
+	}
+
+	return nil
+}
+
+// weights returns pointers to all tunable values, the weights, the list entries and the
+// table entries on the board
+func (p *EvalParams) weights() []*int {
+	v := reflect.ValueOf(p).Elem()
+	values := []*int{}
+
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Tag.Get("tune") == "-" {
+			continue
+		}
+
+		switch field := v.Field(i).Addr().Interface().(type) {
+		case *int:
+			values = append(values, field)
+		case *EvalWeight:
+			values = append(values, &field.Middle, &field.End)
+		case *EvalList:
+			for i := range *field {
+				values = append(values, &(*field)[i])
+			}
+		case *[]int:
+			for sq := range *field {
+				if sq&0x88 == 0 {
+					values = append(values, &(*field)[sq])
+				}
+			}
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,256 @@
+package engine
+
+const (
+	pawnHashSize = 1 << 14
+
+	// a blocked passed pawn keeps only a part of its bonus
+	evalPassedPawnBlockedDivisor = 2
+	// endgame bonus per square of king distance to the square in front of a passed pawn
+	evalPassedPawnEnemyKing = 5
+	evalPassedPawnOwnKing   = 2
+)
+
+var (
+	// passed pawn bonus by the rank relative to the side of the pawn
+	passedPawnMiddle = []int{0, 5, 5, 10, 20, 35, 60, 0}
+	passedPawnEnd    = []int{0, 10, 15, 25, 45, 75, 120, 0}
+)
+
+// pawnEntry holds the evaluation of a pawn structure of White and Black
+type pawnEntry struct {
+	key    int64
+	middle [2]int
+	end    [2]int
+	// passed pawns of White and Black by 64 square index
+	passed [2]uint64
+}
+
+// pawnHashTable caches pawn structures by the pawn hash of the board
+type pawnHashTable struct {
+	entries []pawnEntry
+}
+
+func newPawnHashTable() *pawnHashTable {
+	return &pawnHashTable{entries: make([]pawnEntry, pawnHashSize)}
+}
+
+// evaluatePawns returns the pawn structure scores of White and Black for the midgame and
+// the endgame. The structure is cached in the table if one is given.
+func evaluatePawns(b *Board, table *pawnHashTable, p *EvalParams) ([2]int, [2]int) {
+	var entry pawnEntry
+
+	if table != nil && b.zobristTable != nil {
+		slot := &table.entries[uint64(b.pawnHash)%pawnHashSize]
+		if slot.key != b.pawnHash {
+			*slot = pawnStructure(b, p)
+			slot.key = b.pawnHash
+		}
+		entry = *slot
+	} else {
+		entry = pawnStructure(b, p)
+	}
+
+	middle, end := entry.middle, entry.end
+
+	for side, color := range []int8{White, Black} {
+		middlePassed, endPassed := evaluatePassedPawns(b, entry.passed[side], color, p)
+		middle[side] += middlePassed
+		end[side] += endPassed
+	}
+
+	return middle, end
+}
+
+// pawnStructure evaluates the terms depending on the pawns only
+func pawnStructure(b *Board, p *EvalParams) pawnEntry {
+	entry := pawnEntry{}
+
+	// lowest and highest rank of the pawns on each file, indexed by White and Black
+	var lowest, highest [2][size]int8
+	var count [2][size]int
+	for side := 0; side < 2; side++ {
+		for f := int8(0); f < size; f++ {
+			lowest[side][f], highest[side][f] = size, -1
+		}
+	}
+
+	for sq := int8(0); sq < boardSize; sq++ {
+		if !b.legalSquare(sq) || abs(b.data[sq]) != Pawn {
+			continue
+		}
+		side := 0
+		if b.data[sq] < 0 {
+			side = 1
+		}
+		r, f := rank(sq), file(sq)
+		count[side][f]++
+		if r < lowest[side][f] {
+			lowest[side][f] = r
+		}
+		if r > highest[side][f] {
+			highest[side][f] = r
+		}
+	}
+
+	middle, end := &entry.middle, &entry.end
+
+	for sq := int8(0); sq < boardSize; sq++ {
+		if !b.legalSquare(sq) || abs(b.data[sq]) != Pawn {
+			continue
+		}
+		pawn := b.data[sq]
+		side, enemy := 0, 1
+		if pawn < 0 {
+			side, enemy = 1, 0
+		}
+		r, f := rank(sq), file(sq)
+		forward := pawn * nextRank
+
+		// ahead returns whether rank a is in front of rank c for the pawn
+		ahead := func(a, c int8) bool {
+			if pawn > 0 {
+				return a > c
+			}
+			return a < c
+		}
+
+		isolated, passed, supported := true, true, false
+		for adjacent := f - 1; adjacent <= f+1; adjacent++ {
+			if adjacent < 0 || adjacent >= size {
+				continue
+			}
+
+			// the enemy pawn most in front of its own side
+			front := lowest[enemy][adjacent]
+			if pawn < 0 {
+				front = highest[enemy][adjacent]
+			}
+			if count[enemy][adjacent] > 0 && ahead(front, r) {
+				passed = false
+			}
+
+			if adjacent == f {
+				continue
+			}
+			if count[side][adjacent] > 0 {
+				isolated = false
+			}
+
+			// a pawn on the same rank or behind can still support this one
+			back := lowest[side][adjacent]
+			if pawn < 0 {
+				back = highest[side][adjacent]
+			}
+			if count[side][adjacent] > 0 && !ahead(back, r) {
+				supported = true
+			}
+		}
+
+		// only the pawn most in front on its file is passed
+		if pawn > 0 && highest[side][f] != r || pawn < 0 && lowest[side][f] != r {
+			passed = false
+		}
+
+		connected := false
+		for _, neighbour := range []int8{sq - 1, sq + 1, sq - forward - 1, sq - forward + 1} {
+			if b.legalSquare(neighbour) && b.data[neighbour] == pawn {
+				connected = true
+			}
+		}
+
+		// backward: no pawn can support it and an enemy pawn controls the square in front
+		backward := false
+		if !isolated && !supported {
+			for _, attacker := range []int8{sq + 2*forward - 1, sq + 2*forward + 1} {
+				if b.legalSquare(attacker) && b.data[attacker] == -pawn {
+					backward = true
+				}
+			}
+		}
+
+		switch {
+		case isolated:
+			middle[side] += p.IsolatedPawn.Middle
+			end[side] += p.IsolatedPawn.End
+		case backward:
+			middle[side] += p.BackwardPawn.Middle
+			end[side] += p.BackwardPawn.End
+		}
+
+		if connected {
+			middle[side] += p.ConnectedPawn.Middle
+			end[side] += p.ConnectedPawn.End
+		}
+
+		if passed {
+			entry.passed[side] |= 1 << uint(r*size+f)
+		}
+	}
+
+	for side := 0; side < 2; side++ {
+		islands := 0
+		for f := int8(0); f < size; f++ {
+			if count[side][f] > 1 {
+				middle[side] += (count[side][f] - 1) * p.DoubledPawn.Middle
+				end[side] += (count[side][f] - 1) * p.DoubledPawn.End
+			}
+			if count[side][f] > 0 && (f == 0 || count[side][f-1] == 0) {
+				islands++
+			}
+		}
+		if islands > 1 {
+			middle[side] += (islands - 1) * p.PawnIsland.Middle
+			end[side] += (islands - 1) * p.PawnIsland.End
+		}
+	}
+
+	return entry
+}
+
+// evaluatePassedPawns scores the passed pawns of a side by rank, blockers and king distance
+func evaluatePassedPawns(b *Board, passed uint64, color int8, p *EvalParams) (int, int) {
+	ownKing, enemyKing := int8(b.whiteKingPosition), int8(b.blackKingPosition)
+	if color == Black {
+		ownKing, enemyKing = enemyKing, ownKing
+	}
+
+	middle, end := 0, 0
+
+	for index := int8(0); index < size*size; index++ {
+		if passed&(1<<uint(index)) == 0 {
+			continue
+		}
+
+		sq := square(index/size, index%size)
+		relative := rank(sq)
+		if color == Black {
+			relative = size - 1 - relative
+		}
+
+		bonusMiddle, bonusEnd := p.PassedPawnMiddle[relative], p.PassedPawnEnd[relative]
+
+		stop := sq + color*nextRank
+		if b.data[stop] != Empty {
+			bonusMiddle /= p.PassedPawnBlockedDivisor
+			bonusEnd /= p.PassedPawnBlockedDivisor
+		}
+
+		// the kings decide the race of a pawn in the endgame
+		weight := int(relative) - 1
+		bonusEnd += weight * (p.PassedPawnEnemyKing*distance(enemyKing, stop) - p.PassedPawnOwnKing*distance(ownKing, stop))
+
+		middle += bonusMiddle
+		end += bonusEnd
+	}
+
+	return middle, end
+}
+
+// distance returns the number of king moves between two squares
+func distance(a, b int8) int {
+	ranks, files := abs(rank(a)-rank(b)), abs(file(a)-file(b))
+	if ranks > files {
+		return int(ranks)
+	}
+	return int(files)
+}
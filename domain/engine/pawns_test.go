@@ -0,0 +1,73 @@
+package engine
+
+import "testing"
+
+func TestPawnStructureFindsPassedPawns(t *testing.T) {
+	b, _ := parseFEN("4k3/p7/8/2P5/8/1p6/P7/4K3 w - - 0 1")
+	entry := pawnStructure(b, &defaultEvalParams)
+
+	// a2 faces b3, while b3 and a7 both face a2
+	if entry.passed[0] != 1<<uint(4*size+2) {
+		t.Errorf("Expected c5 to be the only white passed pawn but found %b\n", entry.passed[0])
+	}
+	if entry.passed[1] != 0 {
+		t.Errorf("Expected no black passed pawn but found %b\n", entry.passed[1])
+	}
+}
+
+func TestPawnStructurePenalizesIsolatedAndDoubledPawns(t *testing.T) {
+	healthy, _ := parseFEN("4k3/8/8/8/8/8/PPP5/4K3 w - - 0 1")
+	broken, _ := parseFEN("4k3/8/8/8/8/2P5/P1P5/4K3 w - - 0 1")
+
+	healthyMiddle, _ := evaluatePawns(healthy, nil, &defaultEvalParams)
+	brokenMiddle, _ := evaluatePawns(broken, nil, &defaultEvalParams)
+
+	if brokenMiddle[0] >= healthyMiddle[0] {
+		t.Errorf("Expected a worse structure with isolated and doubled pawns but found %d and %d\n", brokenMiddle[0], healthyMiddle[0])
+	}
+}
+
+func TestPassedPawnNeedsKingSupportInEndgame(t *testing.T) {
+	supported, _ := parseFEN("8/8/2K5/2P5/8/8/8/6k1 w - - 0 1")
+	stopped, _ := parseFEN("8/2k5/8/2P5/8/8/8/6K1 w - - 0 1")
+
+	_, supportedEnd := evaluatePawns(supported, nil, &defaultEvalParams)
+	_, stoppedEnd := evaluatePawns(stopped, nil, &defaultEvalParams)
+
+	if supportedEnd[0] <= stoppedEnd[0] {
+		t.Errorf("Expected the supported pawn to be worth more but found %d and %d\n", supportedEnd[0], stoppedEnd[0])
+	}
+}
+
+func TestPawnHashFollowsMoves(t *testing.T) {
+	b := NewBoard("rnbqkbnr/1ppp1ppp/8/3Pp3/8/8/pPP1PPPP/RNBQKBNR w KQkq e6 0 1")
+	initial := b.pawnHash
+
+	for _, str := range []string{"d5e6", "a2b1q"} {
+		move, err := b.LegalMove(str)
+		if err != nil {
+			t.Fatalf("Expected %s to be legal: %s\n", str, err)
+		}
+		b.MakeMove(move)
+		if b.pawnHash != b.generatePawnHash() {
+			t.Errorf("Expected the pawn hash to match the pawns after %s\n", str)
+		}
+	}
+
+	b.UndoMove()
+	b.UndoMove()
+	if b.pawnHash != initial {
+		t.Errorf("Expected the pawn hash to be restored by undo\n")
+	}
+}
+
+func TestPawnHashTableMatchesEvaluation(t *testing.T) {
+	b := NewBoard("r1bqkb1r/pp3ppp/2n1pn2/2pp4/3P4/2PBPN2/PP3PPP/RNBQK2R w KQkq - 0 1")
+	table := newPawnHashTable()
+
+	for i := 0; i < 2; i++ {
+		if cached, plain := evaluate(b, table), Evaluate(b); cached != plain {
+			t.Errorf("Expected the cached evaluation %d to equal %d\n", cached, plain)
+		}
+	}
+}
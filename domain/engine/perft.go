@@ -1,6 +1,12 @@
 package engine
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
 
 var (
 	position1FEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
@@ -45,69 +51,122 @@ func Perft(fen string, expected []PerftData) {
 	printPerftData(NewBoard(fen), expected)
 }
 
+// perft counts the leaf nodes of the move tree of a given depth, with the captures, en
+// passants, castles, promotions, checks and mates of the moves leading to them
 func perft(depth int, board *Board) PerftData {
 
 	data := PerftData{depth: depth}
-	generator := NewGenerator(board)
-
 	start := time.Now()
 
 	if depth == 0 {
-		data.depth = 0
 		data.nodes = 1
 		return data
 	}
 
-	moves := generator.GenerateMoves()
+	data.walk(depth, board, make([]Generator, depth+1), make([]MoveBuffer, depth+1))
+	data.elapsed = time.Since(start)
 
-	if len(moves) == 0 {
-		data.mates++
-	}
+	return data
+}
 
-	if generator.kingUnderCheck {
-		data.checks++
-	}
+// walk adds the leaf nodes below the board, with a generator and a move buffer per depth
+func (data *PerftData) walk(depth int, board *Board, generators []Generator, buffers []MoveBuffer) {
+	generator := &generators[depth]
+	generator.board = board
 
-	for _, move := range moves {
+	for _, move := range generator.GenerateMovesInto(&buffers[depth]) {
 		board.MakeMove(move)
+		assertIncremental(board, move)
 
-		res := perft(depth-1, board)
-		data.nodes += res.nodes
-		data.captures += res.captures
-		data.enPassants += res.enPassants
-		data.castles += res.castles
-		data.promotions += res.promotions
-		data.checks += res.checks
-		data.mates += res.mates
-
-		switch move.Special {
-		case moveCastelingShort:
-			data.castles++
-		case moveCastelingLong:
-			data.castles++
-		case movePromotion:
-			data.promotions++
-		case moveEnPassant:
-			data.enPassants++
+		if depth > 1 {
+			data.walk(depth-1, board, generators, buffers)
+		} else {
+			data.countLeaf(board, move, &generators[0], &buffers[0])
 		}
 
-		if move.Content != Empty {
-			data.captures++
-		}
+		board.UndoMove()
+		assertIncremental(board, move)
+	}
+}
 
-		switch board.status {
-		case statusCheck:
-			data.checks++
-		case statusBlackMates:
-			data.mates++
-		case statusWhiteMates:
+// countLeaf counts a move to a leaf node, the board is after the move
+func (data *PerftData) countLeaf(board *Board, move Move, generator *Generator, buffer *MoveBuffer) {
+	data.nodes++
+
+	switch move.Special {
+	case moveCastelingShort, moveCastelingLong:
+		data.castles++
+	case movePromotion:
+		data.promotions++
+	case moveEnPassant:
+		data.enPassants++
+	}
+
+	if move.Content != Empty {
+		data.captures++
+	}
+
+	generator.board = board
+	if generator.CheckSimple() {
+		data.checks++
+		if len(generator.GenerateMovesInto(buffer)) == 0 {
 			data.mates++
 		}
+	}
+}
+
+// assertIncremental stops perft in debug mode once the incremental evaluation drifts
+func assertIncremental(board *Board, move Move) {
+	if !debugIncremental {
+		return
+	}
+	if err := board.checkIncremental(); err != nil {
+		panic(fmt.Sprintf("%s at %s", err, move.UciString()))
+	}
+}
+
+// PerftDivision is the number of leaf nodes below a root move
+type PerftDivision struct {
+	Move  Move
+	Nodes int64
+}
+
+// PerftDivide counts the leaf nodes of a given depth below every root move, sorted by
+// the moves in UCI notation so the output compares line by line with other engines
+func PerftDivide(depth int, board *Board) []PerftDivision {
+	divisions := []PerftDivision{}
+	if depth < 1 {
+		return divisions
+	}
 
+	for _, move := range NewGenerator(board).GenerateMoves() {
+		board.MakeMove(move)
+		divisions = append(divisions, PerftDivision{Move: move, Nodes: perft(depth-1, board).nodes})
 		board.UndoMove()
 	}
 
-	data.elapsed = time.Since(start)
+	sort.Slice(divisions, func(i, j int) bool {
+		return divisions[i].Move.UciString() < divisions[j].Move.UciString()
+	})
+	return divisions
+}
 
-	return data
+// runPerftDivide runs "perft divide <depth>" on the board and prints the nodes per root move
+func runPerftDivide(board *Board, args []string) error {
+	if len(args) != 1 {
+		return errors.New("missing depth")
+	}
+	depth, err := strconv.Atoi(args[0])
+	if err != nil || depth < 1 {
+		return fmt.Errorf("invalid depth value: %s", args[0])
+	}
+
+	var total int64
+	start := time.Now()
+	for _, division := range PerftDivide(depth, board) {
+		fmt.Printf("%s: %d\n", division.Move.UciString(), division.Nodes)
+		total += division.Nodes
+	}
+	fmt.Printf("\nnodes %d in %ss\n", total, formatDuration(time.Since(start)))
+	return nil
 }
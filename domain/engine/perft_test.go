@@ -0,0 +1,53 @@
+package engine
+
+import "testing"
+
+func TestPerftTables(t *testing.T) {
+	tests := []struct {
+		fen      string
+		expected []PerftData
+	}{
+		{position1FEN, position1Table[:5]},
+		{position2FEN, position2Table[:4]},
+	}
+
+	for _, test := range tests {
+		b := NewBoard(test.fen)
+		for _, expected := range test.expected {
+			data := perft(expected.depth, b)
+			data.elapsed = 0
+			if data != expected {
+				t.Errorf("Expected %+v but found %+v in %s\n", expected, data, test.fen)
+			}
+		}
+	}
+}
+
+func TestPerftNodes(t *testing.T) {
+	tests := []struct {
+		fen   string
+		depth int
+		nodes int64
+	}{
+		{"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 5, 674624},
+		{"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 4, 422333},
+		{"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", 3, 62379},
+		{"r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10", 3, 89890},
+		{"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1", 4, 182838},
+	}
+
+	for _, test := range tests {
+		if data := perft(test.depth, NewBoard(test.fen)); data.nodes != test.nodes {
+			t.Errorf("Expected %d nodes at depth %d but found %d in %s\n", test.nodes, test.depth, data.nodes, test.fen)
+		}
+	}
+}
+
+func BenchmarkPerft(b *testing.B) {
+	board := NewBoard(position2FEN)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		perft(3, board)
+	}
+}
@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PerftSuitePosition is a position of a perft suite with the expected leaf nodes per depth
+type PerftSuitePosition struct {
+	FEN      string
+	Expected []PerftData
+}
+
+// PerftSuiteResult is the outcome of a position of a perft suite. A failed position
+// reports the first depth with a wrong node count, a passed one the deepest depth.
+type PerftSuiteResult struct {
+	FEN      string
+	Depth    int
+	Expected int64
+	Found    int64
+}
+
+// Passed checks whether all depths counted the expected nodes
+func (r PerftSuiteResult) Passed() bool {
+	return r.Expected == r.Found
+}
+
+func (r PerftSuiteResult) String() string {
+	if r.Passed() {
+		return fmt.Sprintf("pass depth %d: %d nodes in %s", r.Depth, r.Found, r.FEN)
+	}
+	return fmt.Sprintf("fail depth %d: expected %d but found %d nodes in %s", r.Depth, r.Expected, r.Found, r.FEN)
+}
+
+// LoadPerftSuite reads a perft suite in the EPD format of perftsuite.epd, a position
+// per line followed by the node counts like "<fen> ;D1 20 ;D2 400", ignoring comments
+func LoadPerftSuite(path string) ([]PerftSuitePosition, error) {
+	input, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	positions := []PerftSuitePosition{}
+	scanner := bufio.NewScanner(input)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		position, err := parsePerftSuiteLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		positions = append(positions, position)
+	}
+
+	return positions, scanner.Err()
+}
+
+// parsePerftSuiteLine parses a position and its node counts
+func parsePerftSuiteLine(text string) (PerftSuitePosition, error) {
+	fields := strings.Split(text, ";")
+	position := PerftSuitePosition{FEN: strings.TrimSpace(fields[0])}
+	if _, err := parseFEN(position.FEN); err != nil {
+		return position, err
+	}
+
+	for _, field := range fields[1:] {
+		words := strings.Fields(field)
+		if len(words) != 2 || !strings.HasPrefix(words[0], "D") {
+			return position, fmt.Errorf("invalid node count: %s", strings.TrimSpace(field))
+		}
+		depth, err := strconv.Atoi(words[0][1:])
+		if err != nil || depth < 1 {
+			return position, fmt.Errorf("invalid depth: %s", words[0])
+		}
+		nodes, err := strconv.ParseInt(words[1], 10, 64)
+		if err != nil || nodes < 0 {
+			return position, fmt.Errorf("invalid nodes: %s", words[1])
+		}
+		position.Expected = append(position.Expected, PerftData{depth: depth, nodes: nodes})
+	}
+
+	if len(position.Expected) == 0 {
+		return position, errors.New("missing node counts")
+	}
+	return position, nil
+}
+
+// RunPerftSuite counts the nodes of a position at its depths up to a maximum depth,
+// a maximum depth of 0 runs all depths
+func RunPerftSuite(position PerftSuitePosition, maxDepth int) PerftSuiteResult {
+	result := PerftSuiteResult{FEN: position.FEN}
+	board := NewBoard(position.FEN)
+
+	for _, expected := range position.Expected {
+		if maxDepth > 0 && expected.depth > maxDepth {
+			break
+		}
+		result.Depth, result.Expected = expected.depth, expected.nodes
+		if result.Found = perft(expected.depth, board).nodes; !result.Passed() {
+			break
+		}
+	}
+	return result
+}
+
+// runPerftSuite runs "perftsuite <positions> [<depth>]" and prints pass or fail per position
+func runPerftSuite(args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing positions file")
+	}
+	maxDepth := 0
+	if len(args) > 1 {
+		value, err := strconv.Atoi(args[1])
+		if err != nil || value < 1 {
+			return fmt.Errorf("invalid depth value: %s", args[1])
+		}
+		maxDepth = value
+	}
+
+	positions, err := LoadPerftSuite(args[0])
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	for _, position := range positions {
+		result := RunPerftSuite(position, maxDepth)
+		if !result.Passed() {
+			failures++
+		}
+		fmt.Println(result)
+	}
+	fmt.Printf("checked %d positions, %d failures\n", len(positions), failures)
+	return nil
+}
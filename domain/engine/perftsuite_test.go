@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// perftSuiteTestNodes keeps the depths of the suite the tests run short
+const perftSuiteTestNodes = 500000
+
+func TestPerftSuite(t *testing.T) {
+	positions, err := LoadPerftSuite(filepath.Join("testdata", "perftsuite.epd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, position := range positions {
+		position := position
+		t.Run(position.FEN, func(t *testing.T) {
+			maxDepth := 1
+			for _, expected := range position.Expected {
+				if expected.nodes <= perftSuiteTestNodes {
+					maxDepth = expected.depth
+				}
+			}
+			if result := RunPerftSuite(position, maxDepth); !result.Passed() {
+				t.Error(result)
+			}
+		})
+	}
+}
+
+func TestLoadPerftSuite(t *testing.T) {
+	path := writePerftSuite(t, "# suite\n\n"+position1FEN[:len(position1FEN)-4]+" ;D1 20 ;D2 400\n8/8/8/8/8/8/6k1/4K2R w K - ;D1 12\n")
+
+	positions, err := LoadPerftSuite(path)
+	if err != nil || len(positions) != 2 {
+		t.Fatalf("Expected two positions but found %v (%v)\n", positions, err)
+	}
+	if positions[0].FEN != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -" {
+		t.Errorf("Expected the start position but found %s\n", positions[0].FEN)
+	}
+	if expected := positions[0].Expected; len(expected) != 2 || expected[1] != (PerftData{depth: 2, nodes: 400}) {
+		t.Errorf("Expected the node counts of two depths but found %+v\n", expected)
+	}
+}
+
+func TestLoadPerftSuiteRejectsBrokenLines(t *testing.T) {
+	for _, line := range []string{
+		"8/8/8/8/8/8/6k1/4K2R w K -",
+		"8/8/8/8/8/8/6k1/4K2R w K - ;D1",
+		"8/8/8/8/8/8/6k1/4K2R w K - ;X1 12",
+		"8/8/8/8/8/8/6k1/4K2R w K - ;D0 1",
+		"8/8/8/8/8/8/6k1/4K2R w K - ;D1 many",
+		"8/8/8/8/8/8/6k1/4K2X w K - ;D1 12",
+	} {
+		if _, err := LoadPerftSuite(writePerftSuite(t, line+"\n")); err == nil {
+			t.Errorf("Expected %q to be rejected\n", line)
+		}
+	}
+}
+
+func TestRunPerftSuiteReportsFirstFailure(t *testing.T) {
+	position := PerftSuitePosition{FEN: defaultFEN, Expected: []PerftData{{depth: 1, nodes: 20}, {depth: 2, nodes: 401}, {depth: 3, nodes: 8902}}}
+
+	result := RunPerftSuite(position, 0)
+	if result.Passed() || result.Depth != 2 || result.Found != 400 {
+		t.Errorf("Expected a failure at depth 2 but found %s\n", result)
+	}
+	if result := RunPerftSuite(position, 1); !result.Passed() || result.Depth != 1 {
+		t.Errorf("Expected depth 1 to pass but found %s\n", result)
+	}
+}
+
+func TestPerftDivide(t *testing.T) {
+	b := NewBoard(position2FEN)
+	divisions := PerftDivide(3, b)
+
+	if len(divisions) != 48 {
+		t.Fatalf("Expected 48 root moves but found %d\n", len(divisions))
+	}
+	var total int64
+	for i, division := range divisions {
+		total += division.Nodes
+		if i > 0 && divisions[i-1].Move.UciString() >= division.Move.UciString() {
+			t.Errorf("Expected the moves to be sorted but found %s before %s\n", divisions[i-1].Move.UciString(), division.Move.UciString())
+		}
+	}
+	if total != 97862 {
+		t.Errorf("Expected 97862 nodes but found %d\n", total)
+	}
+	if fen := generateFEN(b); fen != generateFEN(NewBoard(position2FEN)) {
+		t.Errorf("Expected the board to be unchanged but found %s\n", fen)
+	}
+	if divisions := PerftDivide(0, b); len(divisions) != 0 {
+		t.Errorf("Expected no divisions at depth 0 but found %d\n", len(divisions))
+	}
+}
+
+func writePerftSuite(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "perftsuite.epd")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,80 @@
+package engine
+
+// Position is an immutable chess position. It never changes once created, so goroutines
+// may share it, Apply returns a new position instead. Create positions with NewPosition
+// or NewPositionOf.
+type Position struct {
+	board *Board
+}
+
+// NewPosition creates a position from a FEN
+func NewPosition(fen string) (Position, error) {
+	b, err := newBoard(fen)
+	if err != nil {
+		return Position{}, err
+	}
+	return Position{board: b}, nil
+}
+
+// NewPositionOf creates a position from the current state of a board, later moves on the
+// board do not change it
+func NewPositionOf(b *Board) Position {
+	return Position{board: b.Clone()}
+}
+
+// Apply returns the position after a legal move, the position itself stays unchanged
+func (p Position) Apply(m Move) Position {
+	b := p.board.Clone()
+	b.MakeMove(m)
+	return Position{board: b}
+}
+
+// Board returns a board of the position to play moves on or to search
+func (p Position) Board() *Board {
+	return p.board.Clone()
+}
+
+// FEN returns the position in Forsyth-Edwards notation
+func (p Position) FEN() string {
+	return p.board.FEN()
+}
+
+// Hash returns the Zobrist hash of the position
+func (p Position) Hash() int64 {
+	return p.board.Hash()
+}
+
+// PieceAt returns the piece on a square
+func (p Position) PieceAt(sq Square) int8 {
+	return p.board.PieceAt(sq)
+}
+
+// SideToMove returns White or Black
+func (p Position) SideToMove() int8 {
+	return p.board.SideToMove()
+}
+
+// History returns the moves that led to the position
+func (p Position) History() []Move {
+	return p.board.History()
+}
+
+// Status returns the state of the game, see Board.Status
+func (p Position) Status() string {
+	return p.board.Status()
+}
+
+// LegalMoves returns the legal moves of the side to move
+func (p Position) LegalMoves() []Move {
+	return p.board.LegalMoves()
+}
+
+// LegalMove parses a move in UCI notation, see Board.LegalMove
+func (p Position) LegalMove(str string) (Move, error) {
+	return p.board.LegalMove(str)
+}
+
+// IsLegal checks a move of the side to move
+func (p Position) IsLegal(m Move) bool {
+	return p.board.IsLegal(m)
+}
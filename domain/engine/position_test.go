@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCloneDoesNotShareHistory(t *testing.T) {
+	b := NewBoard(defaultFEN)
+	for _, str := range []string{"e2e4", "e7e5", "g1f3"} {
+		move, _ := b.LegalMove(str)
+		b.MakeMove(move)
+	}
+	// the history keeps spare capacity after an undo
+	b.UndoMove()
+
+	clone := b.Clone()
+	knight, _ := clone.LegalMove("b1c3")
+	clone.MakeMove(knight)
+	bishop, _ := b.LegalMove("f1c4")
+	b.MakeMove(bishop)
+
+	if history := clone.History(); history[len(history)-1] != knight {
+		t.Errorf("Expected the clone to keep its own last move but found %s\n", history[len(history)-1].UciString())
+	}
+	if b.FEN() == clone.FEN() {
+		t.Error("Expected the boards to differ")
+	}
+}
+
+func TestCloneDoesNotShareNetwork(t *testing.T) {
+	b := NewBoard(position2FEN)
+	b.attachNetwork(randomNetwork(8, 3))
+
+	clone := b.Clone()
+	for _, move := range clone.LegalMoves() {
+		clone.MakeMove(move)
+		checkAccumulator(t, clone.network.net, clone, move)
+		clone.UndoMove()
+	}
+	checkAccumulator(t, b.network.net, b, Move{})
+
+	// moves on the original leave the accumulator of the clone alone
+	b.MakeMove(b.LegalMoves()[0])
+	checkAccumulator(t, clone.network.net, clone, Move{})
+}
+
+func TestPositionApply(t *testing.T) {
+	p, err := NewPosition(defaultFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	move, _ := p.LegalMove("e2e4")
+
+	next := p.Apply(move)
+	if p.FEN() != defaultFEN || len(p.History()) != 0 {
+		t.Errorf("Expected the position to stay unchanged but found %s\n", p.FEN())
+	}
+	if next.SideToMove() != Black || !reflect.DeepEqual(next.History(), []Move{move}) {
+		t.Errorf("Expected black to move after e2e4 but found %s\n", next.FEN())
+	}
+
+	// a board of the position does not change it
+	b := next.Board()
+	b.MakeMove(b.LegalMoves()[0])
+	if next.SideToMove() != Black {
+		t.Error("Expected the board to be a copy")
+	}
+
+	if _, err := NewPosition("8/8/8"); err == nil {
+		t.Error("Expected an invalid FEN to be rejected")
+	}
+}
+
+func TestPositionConcurrentReaders(t *testing.T) {
+	p := NewPositionOf(NewBoard(position2FEN))
+	expected := map[string]bool{}
+	for _, move := range p.LegalMoves() {
+		expected[p.Apply(move).FEN()] = true
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, move := range p.LegalMoves() {
+				if next := p.Apply(move); !expected[next.FEN()] || next.Status() == "" {
+					t.Errorf("Expected a known position after %s\n", move.UciString())
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if p.FEN() != "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1" {
+		t.Errorf("Expected the position to stay unchanged but found %s\n", p.FEN())
+	}
+}
@@ -0,0 +1,147 @@
+package engine
+
+// relativeSquare returns the square as seen from the side, Black sees the board flipped
+func relativeSquare(sq int8, side int) int8 {
+	if side == 1 {
+		return int8(flipTable[sq])
+	}
+	return sq
+}
+
+// evaluatePiece adds the positional terms of a knight, bishop or rook
+func evaluatePiece(b *Board, sq int8, side int, p *EvalParams, terms *evalTerms) {
+	piece := b.data[sq]
+	relative := relativeSquare(sq, side)
+
+	switch abs(piece) {
+	case Knight:
+		if isOutpost(b, sq) {
+			terms.addWeight("KnightOutpost", side, p.KnightOutpost)
+		}
+
+	case Bishop:
+		if isOutpost(b, sq) {
+			terms.addWeight("BishopOutpost", side, p.BishopOutpost)
+		}
+
+		// a bishop taking the pawn on a7 or h7 gets locked in by the pawn on b6 or g6
+		enemyPawn := -piece / Bishop * Pawn
+		if relative == int8(A7) && b.data[relativeSquare(int8(B6), side)] == enemyPawn ||
+			relative == int8(H7) && b.data[relativeSquare(int8(G6), side)] == enemyPawn {
+			terms.addWeight("TrappedBishop", side, p.TrappedBishop)
+		}
+
+	case Rook:
+		own, enemy := pawnsOnFile(b, file(sq), piece)
+		if own == 0 && enemy == 0 {
+			terms.addWeight("RookOpenFile", side, p.RookOpenFile)
+		} else if own == 0 {
+			terms.addWeight("RookSemiOpenFile", side, p.RookSemiOpenFile)
+		}
+
+		// the seventh rank matters if the enemy king is cut off or enemy pawns are left there
+		if rank(relative) == size-2 {
+			enemyKing := b.blackKingPosition
+			if side == 1 {
+				enemyKing = b.whiteKingPosition
+			}
+			if rank(relativeSquare(int8(enemyKing), side)) == size-1 || pawnsOnRank(b, rank(sq), -piece/Rook*Pawn) > 0 {
+				terms.addWeight("RookSeventhRank", side, p.RookSeventhRank)
+			}
+		}
+	}
+}
+
+// evaluateCasteling adds the bonus of a castled king and the penalty of a rook trapped
+// by its own king that can no longer castle
+func evaluateCasteling(b *Board, sq int8, side int, p *EvalParams, terms *evalTerms) {
+	rights := b.whiteCastle
+	if side == 1 {
+		rights = b.blackCastle
+	}
+	relative := relativeSquare(sq, side)
+	rook := b.data[sq] / King * Rook
+
+	if rank(relative) != 0 {
+		return
+	}
+
+	switch relative {
+	case int8(G1), int8(H1), int8(B1), int8(C1):
+		if rights == castleNone {
+			terms.addWeight("Casteling", side, p.Casteling)
+		}
+	}
+
+	var trapped []Square
+	switch relative {
+	case int8(F1), int8(G1):
+		if rights&castleShort == 0 {
+			trapped = []Square{G1, H1, H2}
+		}
+	case int8(B1), int8(C1):
+		if rights&castleLong == 0 {
+			trapped = []Square{A1, B1, A2}
+		}
+	}
+
+	for _, rookSquare := range trapped {
+		if b.data[relativeSquare(int8(rookSquare), side)] == rook {
+			terms.addWeight("TrappedRook", side, p.TrappedRook)
+			break
+		}
+	}
+}
+
+// isOutpost checks whether a minor piece on the enemy half is protected by a pawn and
+// can not be driven away by an enemy pawn
+func isOutpost(b *Board, sq int8) bool {
+	piece := b.data[sq]
+	color := piece / abs(piece)
+
+	relativeRank := rank(sq)
+	if color == Black {
+		relativeRank = size - 1 - relativeRank
+	}
+	if relativeRank < 3 || relativeRank > 5 || !attackedByPawn(b, sq, piece) {
+		return false
+	}
+
+	enemyPawn := -color * Pawn
+	for target := sq + color*nextRank; b.legalSquare(target); target += color * nextRank {
+		for _, attacker := range []int8{target - 1, target + 1} {
+			if b.legalSquare(attacker) && b.data[attacker] == enemyPawn {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// pawnsOnFile counts the pawns of the side of the piece and of the enemy on the file
+func pawnsOnFile(b *Board, f int8, piece int8) (int, int) {
+	own, enemy := 0, 0
+	for r := int8(0); r < size; r++ {
+		content := b.data[square(r, f)]
+		if abs(content) != Pawn {
+			continue
+		}
+		if content*piece > 0 {
+			own++
+		} else {
+			enemy++
+		}
+	}
+	return own, enemy
+}
+
+// pawnsOnRank counts the given pawns on the rank
+func pawnsOnRank(b *Board, r int8, pawn int8) int {
+	count := 0
+	for f := int8(0); f < size; f++ {
+		if b.data[square(r, f)] == pawn {
+			count++
+		}
+	}
+	return count
+}
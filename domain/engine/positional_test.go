@@ -0,0 +1,77 @@
+package engine
+
+import "testing"
+
+func TestPositionalTerms(t *testing.T) {
+	tests := []struct {
+		term string
+		fen  string
+		side int
+	}{
+		{"BishopPair", "4k3/8/8/8/8/8/8/2B1KB2 w - - 0 1", 0},
+		{"RookOpenFile", "4k3/p7/8/8/8/8/P7/3RK3 w - - 0 1", 0},
+		{"RookSemiOpenFile", "4k3/3p4/8/8/8/8/8/3RK3 w - - 0 1", 0},
+		{"RookSeventhRank", "6k1/8/8/8/8/8/1r6/6K1 b - - 0 1", 1},
+		{"KnightOutpost", "4k3/8/8/3N4/4P3/8/8/4K3 w - - 0 1", 0},
+		{"BishopOutpost", "4k3/8/8/3B4/2P5/8/8/4K3 w - - 0 1", 0},
+		{"TrappedBishop", "4k3/B7/1p6/8/8/8/8/4K3 w - - 0 1", 0},
+		{"TrappedRook", "4k3/8/8/8/8/8/8/5K1R w - - 0 1", 0},
+		{"Casteling", "4k3/8/8/8/8/8/5PPP/5RK1 w - - 0 1", 0},
+	}
+
+	for _, test := range tests {
+		b, _ := parseFEN(test.fen)
+		terms, _ := EvaluateBreakdown(b)
+
+		if term := findTerm(terms, test.term); term == nil || term.Middle[test.side] == 0 && term.End[test.side] == 0 {
+			t.Errorf("Expected %s to be scored in %s\n", test.term, test.fen)
+		}
+	}
+}
+
+func TestPositionalTermsNeedTheirCondition(t *testing.T) {
+	tests := []struct {
+		term string
+		fen  string
+	}{
+		// the pawn on c7 can drive the knight away
+		{"KnightOutpost", "4k3/2p5/8/3N4/4P3/8/8/4K3 w - - 0 1"},
+		// the king can still castle and free the rook
+		{"TrappedRook", "4k3/8/8/8/8/8/8/5K1R w K - 0 1"},
+		{"RookOpenFile", "4k3/3p4/8/8/8/8/3P4/3RK3 w - - 0 1"},
+	}
+
+	for _, test := range tests {
+		b, _ := parseFEN(test.fen)
+		terms, _ := EvaluateBreakdown(b)
+
+		if term := findTerm(terms, test.term); term != nil {
+			t.Errorf("Expected no %s in %s\n", test.term, test.fen)
+		}
+	}
+}
+
+func TestBreakdownAddsUpToScore(t *testing.T) {
+	b, _ := parseFEN("r1bq1rk1/pppp1pbp/2n2np1/4p3/2B1P3/2N2N2/PPPP1PPP/R1BQ1RK1 b - - 0 1")
+	terms, score := EvaluateBreakdown(b)
+
+	middle, end := 0, 0
+	for _, term := range terms {
+		middle += term.Middle[0] - term.Middle[1]
+		end += term.End[0] - term.End[1]
+	}
+
+	// all pieces are on the board and black is to move
+	if expected := -taper(middle, end, evalPhaseTotal); score != expected || Evaluate(b) != score {
+		t.Errorf("Expected the terms to add up to %d but found %d\n", score, expected)
+	}
+}
+
+func findTerm(terms []EvalTerm, name string) *EvalTerm {
+	for i := range terms {
+		if terms[i].Name == name {
+			return &terms[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultEvalProfile is the name of the profile used when none is requested
+const DefaultEvalProfile = "default"
+
+// evalProfiles holds the named evaluation parameters, registered profiles are never modified
+var evalProfiles = struct {
+	sync.RWMutex
+	params map[string]*EvalParams
+}{params: map[string]*EvalParams{DefaultEvalProfile: &defaultEvalParams}}
+
+// EvalProfile returns the parameters of a named profile, the default profile for an empty name
+func EvalProfile(name string) (*EvalParams, bool) {
+	if name == "" {
+		name = DefaultEvalProfile
+	}
+
+	evalProfiles.RLock()
+	defer evalProfiles.RUnlock()
+
+	p, ok := evalProfiles.params[name]
+	return p, ok
+}
+
+// RegisterEvalProfile validates the parameters and adds or replaces the profile of the name
+func RegisterEvalProfile(name string, p EvalParams) error {
+	if name == "" {
+		return fmt.Errorf("missing profile name")
+	}
+	if err := p.validate(); err != nil {
+		return fmt.Errorf("profile %s: %s", name, err)
+	}
+
+	params := p.clone()
+
+	evalProfiles.Lock()
+	defer evalProfiles.Unlock()
+
+	evalProfiles.params[name] = &params
+	return nil
+}
+
+// defaultProfile returns the parameters of the default profile
+func defaultProfile() *EvalParams {
+	p, _ := EvalProfile(DefaultEvalProfile)
+	return p
+}
+
+// LoadEvalParams reads parameters from a JSON or YAML file, by its extension. Values missing
+// in the file keep their defaults.
+func LoadEvalParams(path string) (EvalParams, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return EvalParams{}, err
+	}
+
+	p := defaultEvalParams.clone()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &p)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &p)
+	default:
+		return EvalParams{}, fmt.Errorf("unknown parameter format: %s", path)
+	}
+	if err != nil {
+		return EvalParams{}, fmt.Errorf("%s: %s", path, err)
+	}
+
+	if err := p.validate(); err != nil {
+		return EvalParams{}, fmt.Errorf("%s: %s", path, err)
+	}
+	return p, nil
+}
+
+// LoadEvalProfiles registers every JSON and YAML file of a directory as profile named after
+// the file. A file named default replaces the default profile.
+func LoadEvalProfiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || ext != ".json" && ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+
+		p, err := LoadEvalParams(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if err := RegisterEvalProfile(name, p); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDefaultProfileIsDefaultParams(t *testing.T) {
+	p, ok := EvalProfile("")
+	if !ok || p != &defaultEvalParams {
+		t.Fatal("Expected the default profile to use the default parameters")
+	}
+	if err := p.validate(); err != nil {
+		t.Errorf("Expected the default parameters to be valid: %s\n", err)
+	}
+
+	b, _ := parseFEN("r1bq1rk1/pppp1pbp/2n2np1/4p3/2B1P3/2N2N2/PPPP1PPP/R1BQ1RK1 b - - 0 1")
+	loaded, err := LoadEvalParams(writeProfile(t, "default.json", "{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score := evaluateTerms(b, nil, &loaded, &evalTerms{}); score != Evaluate(b) {
+		t.Errorf("Expected an empty profile to score %d but found %d\n", Evaluate(b), score)
+	}
+}
+
+func TestLoadEvalParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"weights.json", `{"bishopPair": {"middle": 40, "end": 60}, "mateSearchLevel": 500}`},
+		{"weights.yaml", "bishopPair:\n  middle: 40\n  end: 60\nmateSearchLevel: 500\n"},
+	}
+
+	for _, test := range tests {
+		p, err := LoadEvalParams(writeProfile(t, test.name, test.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.BishopPair != (EvalWeight{40, 60}) || p.MateSearchLevel != 500 || p.RookOpenFile != defaultEvalParams.RookOpenFile {
+			t.Errorf("Expected %s to override only its values but found %+v\n", test.name, p.BishopPair)
+		}
+	}
+}
+
+func TestLoadEvalParamsOverridesListsAndFactors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"endgame.json", `{"passedPawnEnd": [0, 1, 2, 3, 4, 5, 6, 0], "pawnShield": [0, 9, 0, 0, 0, 0, 0, 0],
+			"kingAttackUnits": [0, 0, 1, 1, 1, 1], "knownWin": 4000, "pushPawn": 30, "scaleNoPawns": 20}`},
+		{"endgame.yaml", "passedPawnEnd: [0, 1, 2, 3, 4, 5, 6, 0]\npawnShield: [0, 9, 0, 0, 0, 0, 0, 0]\n" +
+			"kingAttackUnits: [0, 0, 1, 1, 1, 1]\nknownWin: 4000\npushPawn: 30\nscaleNoPawns: 20\n"},
+	}
+
+	for _, test := range tests {
+		p, err := LoadEvalParams(writeProfile(t, test.name, test.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.PassedPawnEnd[6] != 6 || p.PawnShield[1] != 9 || p.KingAttackUnits[Queen] != 1 ||
+			p.KnownWin != 4000 || p.PushPawn != 30 || p.ScaleNoPawns != 20 {
+			t.Errorf("Expected %s to override its lists and factors but found %+v\n", test.name, p)
+		}
+		if !reflect.DeepEqual(p.PassedPawnMiddle, defaultEvalParams.PassedPawnMiddle) || p.KingAttackersMin != defaultEvalParams.KingAttackersMin {
+			t.Errorf("Expected %s to keep the other defaults\n", test.name)
+		}
+	}
+
+	// the loaded known win decides the score of a known endgame
+	p, _ := LoadEvalParams(writeProfile(t, "win.json", `{"knownWin": 4000}`))
+	b, _ := parseFEN("3k4/8/3K4/8/8/8/8/7R w - - 0 1")
+	if score := evaluateTerms(b, nil, &p, &evalTerms{}); score != Evaluate(b)-(evalKnownWin-4000) {
+		t.Errorf("Expected the known win of the profile to score %d but found %d\n", Evaluate(b)-(evalKnownWin-4000), score)
+	}
+}
+
+func TestLoadEvalParamsKeepsDefaultTables(t *testing.T) {
+	table := strings.Repeat("0, ", evalTableSize-1) + "0"
+	p, err := LoadEvalParams(writeProfile(t, "flat.json", `{"pawnTableMiddle": [`+table+`]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.PawnTableMiddle[square(6, 0)] != 0 || pawnTableMiddle[square(6, 0)] == 0 {
+		t.Error("Expected the loaded table to replace a copy of the default table")
+	}
+}
+
+func TestValidateEvalParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"short.json", `{"knightTableEnd": [1, 2, 3]}`},
+		{"offboard.yaml", "kingTableEnd: [" + strings.Repeat("0, ", 8) + "5" + strings.Repeat(", 0", evalTableSize-9) + "]\n"},
+		{"value.json", `{"queenValue": {"middle": 0, "end": 900}}`},
+		{"level.json", `{"mateSearchLevel": -1}`},
+		{"passed.json", `{"passedPawnMiddle": [0, 5, 5, 10]}`},
+		{"units.yaml", "kingAttackUnits: [0, 0, 2, 2, 3, 5, 9]\n"},
+		{"attacks.json", `{"kingAttackTable": []}`},
+		{"divisor.json", `{"passedPawnBlockedDivisor": 0}`},
+		{"win.yaml", "knownWin: 30000\n"},
+		{"scale.json", `{"scaleOppositeBishops": 65}`},
+		{"params.txt", `{}`},
+	}
+
+	for _, test := range tests {
+		if _, err := LoadEvalParams(writeProfile(t, test.name, test.content)); err == nil {
+			t.Errorf("Expected %s to be rejected\n", test.name)
+		}
+	}
+}
+
+func TestLoadEvalProfiles(t *testing.T) {
+	keepEvalProfiles(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "aggressive.yaml"), []byte("check: {middle: 80, end: 80}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.md"), []byte("not a profile"), 0644)
+
+	names, err := LoadEvalProfiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"aggressive"}) {
+		t.Fatalf("Expected one profile but found %v\n", names)
+	}
+
+	// concurrent requests look up the profile while it is replaced
+	aggressive, _ := EvalProfile("aggressive")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if p, ok := EvalProfile("aggressive"); !ok || p.Check.Middle != 80 {
+				t.Error("Expected the aggressive profile")
+			}
+			RegisterEvalProfile("aggressive", *aggressive)
+		}()
+	}
+	wg.Wait()
+
+	if _, ok := EvalProfile("unknown"); ok {
+		t.Error("Expected no unknown profile")
+	}
+}
+
+func TestKeepEvalProfilesRestoresRegistry(t *testing.T) {
+	t.Run("register", func(t *testing.T) {
+		keepEvalProfiles(t)
+		RegisterEvalProfile("temporary", defaultEvalParams)
+	})
+
+	if _, ok := EvalProfile("temporary"); ok {
+		t.Error("Expected the profile of the subtest to be removed")
+	}
+}
+
+func writeProfile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// keepEvalProfiles restores the registered profiles once the test has finished
+func keepEvalProfiles(t *testing.T) {
+	evalProfiles.RLock()
+	saved := make(map[string]*EvalParams, len(evalProfiles.params))
+	for name, p := range evalProfiles.params {
+		saved[name] = p
+	}
+	evalProfiles.RUnlock()
+
+	t.Cleanup(func() {
+		evalProfiles.Lock()
+		evalProfiles.params = saved
+		evalProfiles.Unlock()
+	})
+}
@@ -1,6 +1,10 @@
 package engine
 
-import "time"
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
 
 var (
 	searchVerbose = true
@@ -14,83 +18,333 @@ const (
 )
 
 type pvSearch struct {
-	board         *Board
-	checkedNodes  int64
-	path          [searchMaxPly][searchMaxPly]Move
-	pathLength    [searchMaxPly]int
-	bestMoves     [searchMaxDepth]Move
-	bestMovesPlys [searchMaxDepth]int
-	bestScores    [searchMaxDepth]int
-	stopByTime    bool
-	stopTime      time.Time
-	followPv      bool
-	ply           int
+	board        *Board
+	checkedNodes int64
+	path         [searchMaxPly][searchMaxPly]PackedMove
+	pathLength   [searchMaxPly]int
+	generators   [searchMaxPly]Generator
+	moves        [searchMaxPly]MoveBuffer
+	excluded     []Move
+	searchMoves  []Move
+	stopByTime   bool
+	maxNodes     int64
+	rootSide     int8
+	rootHistory  int
+	contempt     int
+	evaluator    Evaluator
+	params       *EvalParams
+	control      *searchControl
+	followPv     bool
+	ply          int
+}
+
+// searchControl allows other goroutines to stop a running search or to change its deadline
+type searchControl struct {
+	clock     clock
+	stopped   atomic.Bool
+	pondering atomic.Bool
+	started   atomic.Int64
+	deadline  atomic.Int64
+}
+
+func newSearchControl(c clock) *searchControl {
+	return &searchControl{clock: c}
+}
+
+// start begins the time of the search and sets the hard limit of the time manager
+func (c *searchControl) start(tm *timeManager) {
+	now := c.clock.Now()
+	c.started.Store(now.UnixNano())
+	if tm.limited {
+		c.deadline.Store(now.Add(tm.hard).UnixNano())
+	}
+	c.pondering.Store(false)
+}
+
+func (c *searchControl) elapsed() time.Duration {
+	return time.Duration(c.clock.Now().UnixNano() - c.started.Load())
+}
+
+func (c *searchControl) expired() bool {
+	if c.stopped.Load() {
+		return true
+	}
+	deadline := c.deadline.Load()
+	return deadline != 0 && c.clock.Now().UnixNano() > deadline
+}
+
+// SearchOptions configures a single search
+type SearchOptions struct {
+	// MultiPV is the number of ranked root lines to search, at least one
+	MultiPV int
+	// SearchMoves restricts the root to the given moves if not empty
+	SearchMoves []Move
+	// Mate searches for a forced mate within the given number of moves
+	// without a time limit if greater than zero
+	Mate int
+	// Depth limits the search to the given number of plies if greater than zero
+	Depth int
+	// Nodes stops the search after the given number of nodes if greater than zero
+	Nodes int64
+	// Ponder searches without a time limit until the search job gets a ponder hit
+	Ponder bool
+	// Infinite searches without a time limit until stopped
+	Infinite bool
+	// Clock is the time control, without one a search takes searchMaxTime
+	Clock TimeControl
+	// MoveOverhead is reserved from the clock for communication delays
+	MoveOverhead time.Duration
+	// Params are the evaluation parameters, the default profile if nil
+	Params *EvalParams
+	// Network evaluates instead of the handcrafted evaluation if not nil
+	Network *Network
+	// Skill weakens the play, nil plays at full strength
+	Skill *Skill
+	// Contempt is the value in centipawns the engine gives up to avoid a draw,
+	// a negative contempt makes it seek draws
+	Contempt int
+}
+
+// SearchLine is one ranked root line of a search
+type SearchLine struct {
+	Move  Move
+	Score int
+	Depth int
+	Pv    []Move
 }
 
 // Search finds the best available move
 func Search(board *Board) Move {
+	lines := SearchLines(board, SearchOptions{MultiPV: 1})
+	if len(lines) == 0 {
+		return Move{}
+	}
+	return lines[0].Move
+}
+
+// SearchLines finds the best available root lines ordered by score.
+// Each additional line is searched with the root moves of the better lines excluded.
+func SearchLines(board *Board, options SearchOptions) []SearchLine {
+	return searchLines(board, options, newSearchControl(systemClock{}), newTimeManager(board, options))
+}
+
+func searchLines(board *Board, options SearchOptions, control *searchControl, tm *timeManager) []SearchLine {
 
 	// TODO book
 
-	startTime := time.Now()
+	multiPV := options.MultiPV
+	if multiPV < 1 {
+		multiPV = 1
+	}
 
 	pv := pvSearch{}
-	pv.stopTime = startTime.Add(searchMaxTime)
-	pv.board = &Board{}
-	*pv.board = *board
+	pv.board = board.Clone()
 	pv.board.ply = 0
+	pv.searchMoves = options.SearchMoves
+	pv.control = control
+	pv.rootSide = board.sideToMove
+	pv.rootHistory = len(board.history)
+	pv.contempt = options.Contempt
+	handcrafted := newHandcraftedEvaluator(options.Params)
+	pv.board.setEvalParams(handcrafted.params)
+	pv.evaluator = handcrafted
+	pv.params = handcrafted.params
+	if options.Network != nil {
+		pv.board.attachNetwork(options.Network)
+		pv.evaluator = options.Network
+	}
+
+	maxDepth, mateLimit := searchMaxDepth, searchMaxPly
+	if options.Mate > 0 && options.Mate*2+1 < maxDepth {
+		// a mate in n moves is proven by a search n*2 plies deep
+		maxDepth = options.Mate*2 + 1
+	}
+	if options.Mate > 0 {
+		// check extensions find longer mates, which do not answer the search
+		mateLimit = options.Mate*2 - 1
+	}
+	if options.Depth > 0 && options.Depth+1 < maxDepth {
+		maxDepth = options.Depth + 1
+	}
+	pv.maxNodes = options.Nodes
+
+	// a weakened search chooses among several lines
+	if options.Skill != nil {
+		if multiPV < skillMultiPV {
+			multiPV = skillMultiPV
+		}
+		if options.Skill.maxDepth()+1 < maxDepth {
+			maxDepth = options.Skill.maxDepth() + 1
+		}
+	}
+
+	// a ponder search starts its time with the ponder hit
+	if options.Ponder {
+		control.pondering.Store(true)
+	} else {
+		control.start(tm)
+	}
+
+	rootMoves := pv.countRootMoves()
 
 	// printSearchHead()
 
-	foundMate := false
-	depth := 1
+	lines := []SearchLine{}
+
+	for depth := 1; depth < maxDepth && !pv.stopByTime; depth++ {
+		// the node limit of a skill starts after depth 1, so every skill line has a move
+		if options.Skill != nil && depth > 1 {
+			if nodes := options.Skill.maxNodes(); pv.maxNodes == 0 || nodes < pv.maxNodes {
+				pv.maxNodes = nodes
+			}
+		}
+
+		current := make([]SearchLine, 0, multiPV)
+		pv.excluded = pv.excluded[:0]
+
+		for i := 0; i < multiPV; i++ {
+			// follow the line found for this index on the previous depth
+			if i < len(lines) {
+				for j, move := range lines[i].Pv {
+					pv.path[0][j] = move.Pack()
+				}
+			}
+			pv.followPv = true
+			score := pv.alphaBeta(depth, -searchEvalStart, searchEvalStart)
+
+			if pv.stopByTime {
+				break
+			}
 
-	for ; depth < searchMaxDepth && !pv.stopByTime; depth++ {
-		pv.followPv = true
-		score := pv.alphaBeta(depth, -searchEvalStart, searchEvalStart)
+			// every root move has been excluded
+			if pv.pathLength[0] == 0 {
+				break
+			}
 
-		pv.bestMoves[depth] = pv.path[0][0]
-		pv.bestMovesPlys[depth] = pv.pathLength[0]
-		pv.bestScores[depth] = score
+			line := SearchLine{Pv: pv.principalVariation(), Score: score, Depth: depth}
+			line.Move = line.Pv[0]
 
-		// printSearchLevel(&pv, depth, score, startTime)
+			current = append(current, line)
+			pv.excluded = append(pv.excluded, line.Move)
 
-		if score >= scoreMate || score <= -scoreMate {
-			foundMate = true
+			// printSearchLevel(&pv, depth, score, startTime)
+		}
+
+		// an interrupted depth is incomplete, keep the lines of the last one
+		// unless it is the first depth
+		if pv.stopByTime {
+			if len(lines) == 0 {
+				lines = current
+			}
 			break
 		}
 
+		sort.SliceStable(current, func(i, j int) bool {
+			return current[i].Score > current[j].Score
+		})
+		lines = current
+
+		if allMates(lines, mateLimit) {
+			break
+		}
+
+		if !control.pondering.Load() && tm.stopAfterIteration(control.elapsed(), lines[0], rootMoves) {
+			break
+		}
 	}
 
-	best := Move{}
+	// a search stopped before it finished a line still answers with a legal move
+	if len(lines) == 0 && rootMoves > 0 {
+		lines = []SearchLine{pv.firstRootMove()}
+	}
 
-	if pv.stopByTime {
-		best = pv.bestMoves[depth-2]
+	// printSearchResult(&pv, startTime)
 
-	} else if foundMate {
-		best = pv.bestMoves[depth]
+	if options.Skill != nil && len(lines) > 0 {
+		requested := options.MultiPV
+		if requested < 1 {
+			requested = 1
+		}
+		lines = options.Skill.pick(lines, requested)
+	}
 
-	} else {
-		best = pv.bestMoves[depth-1]
+	return lines
+}
+
+// matedScore scores a mate at the given ply so that shorter mates are preferred
+func matedScore(ply int) int {
+	return scoreMate + searchMaxPly - ply
+}
+
+// matePlies returns the number of plies to the mate of a mate score
+func matePlies(score int) int {
+	if score < 0 {
+		score = -score
 	}
+	return scoreMate + searchMaxPly - score
+}
 
-	// printSearchResult(&pv, startTime)
+// allMates checks whether every line ends in a mate within the given plies
+func allMates(lines []SearchLine, plies int) bool {
+	if len(lines) == 0 {
+		return true
+	}
+	for _, line := range lines {
+		if line.Score < scoreMate && line.Score > -scoreMate || matePlies(line.Score) > plies {
+			return false
+		}
+	}
+	return true
+}
+
+func (pv *pvSearch) countRootMoves() int {
+	count := 0
+	for _, move := range NewGenerator(pv.board).GenerateMoves() {
+		if !pv.skipRootMove(move) {
+			count++
+		}
+	}
+	return count
+}
+
+// firstRootMove returns a line with the first root move that is searched
+func (pv *pvSearch) firstRootMove() SearchLine {
+	pv.excluded = pv.excluded[:0]
+	for _, move := range NewGenerator(pv.board).GenerateMoves() {
+		if !pv.skipRootMove(move) {
+			return SearchLine{Move: move, Pv: []Move{move}}
+		}
+	}
+	return SearchLine{}
+}
 
-	return best
+// skipRootMove checks whether a root move is excluded by multi pv or not part of the search moves
+func (pv *pvSearch) skipRootMove(move Move) bool {
+	for _, m := range pv.excluded {
+		if m == move {
+			return true
+		}
+	}
+
+	if len(pv.searchMoves) == 0 {
+		return false
+	}
+
+	for _, m := range pv.searchMoves {
+		if m.From == move.From && m.To == move.To && (m.Special != movePromotion || m.Promoted == move.Promoted) {
+			return false
+		}
+	}
+	return true
 }
 
 func (pv *pvSearch) alphaBeta(depth, alpha, beta int) int {
 	if depth == 0 {
 		return pv.quiescence(alpha, beta)
 	}
-	pv.checkedNodes++
 
-	// check time all 4096 nodes
-	if pv.checkedNodes%4095 == 0 {
-		if time.Now().After(pv.stopTime) {
-			pv.stopByTime = true
-			return 0
-		}
+	if pv.checkLimits() {
+		return 0
 	}
 
 	// TODO: index out of range
@@ -99,18 +353,19 @@ func (pv *pvSearch) alphaBeta(depth, alpha, beta int) int {
 	}
 	pv.pathLength[pv.board.ply] = pv.board.ply
 
-	generator := Generator{board: pv.board}
-	moves := generator.GenerateMoves()
+	generator := pv.generator()
+	moves := generator.GenerateMovesInto(&pv.moves[pv.board.ply])
 
 	if generator.kingUnderCheck {
 		depth++
 	}
 
 	// repetition
-	if pv.board.ply > 0 && pv.board.repetitions() >= 3 {
-		return scoreDraw
+	if pv.board.ply > 0 && pv.isRepetition() {
+		return pv.drawScore()
 	}
 
+	pv.sortCaptures(moves)
 	if pv.followPv {
 		moves = pv.sortPv(moves)
 	}
@@ -120,6 +375,10 @@ func (pv *pvSearch) alphaBeta(depth, alpha, beta int) int {
 	pvSearch := true
 
 	for _, move := range moves {
+		if pv.board.ply == 0 && pv.skipRootMove(move) {
+			continue
+		}
+
 		pv.board.MakeMove(move)
 		playedMove = true
 
@@ -144,7 +403,7 @@ func (pv *pvSearch) alphaBeta(depth, alpha, beta int) int {
 			alpha = score
 			pvSearch = false
 
-			pv.path[pv.board.ply][pv.board.ply] = move
+			pv.path[pv.board.ply][pv.board.ply] = move.Pack()
 			for j := pv.board.ply + 1; j < pv.pathLength[pv.board.ply+1]; j++ {
 				pv.path[pv.board.ply][j] = pv.path[pv.board.ply+1][j]
 			}
@@ -155,34 +414,78 @@ func (pv *pvSearch) alphaBeta(depth, alpha, beta int) int {
 
 	if !playedMove {
 		if generator.kingUnderCheck {
-			return -(scoreMate + pv.board.ply)
+			return -matedScore(pv.board.ply)
 		}
-		return scoreDraw
+		return pv.drawScore()
 	}
 
 	// fifty moves rule
 	if pv.board.halfMoveClock >= 100 {
-		return scoreDraw
+		return pv.drawScore()
 	}
 
 	return alpha
 }
 
-func (pv *pvSearch) quiescence(alpha, beta int) int {
+// drawScore scores a draw for the side to move with the contempt of the side to move at the root
+func (pv *pvSearch) drawScore() int {
+	if pv.board.sideToMove == pv.rootSide {
+		return scoreDraw - pv.contempt
+	}
+	return scoreDraw + pv.contempt
+}
 
-	pv.checkedNodes++
+// isRepetition checks for a threefold repetition. A position repeated inside the search
+// already counts as a draw since the side to move could force the repetition again.
+func (pv *pvSearch) isRepetition() bool {
+	b := pv.board
 
-	// check time all 4096 nodes
-	if pv.checkedNodes%4095 == 0 {
-		if time.Now().After(pv.stopTime) {
-			pv.stopByTime = true
-			return 0
+	first := len(b.history) - b.halfMoveClock
+	if first < 0 {
+		first = 0
+	}
+
+	count := 0
+	for i := first; i < len(b.history)-1; i++ {
+		if b.history[i].hash == b.currentHash {
+			if i >= pv.rootHistory {
+				return true
+			}
+			count++
 		}
 	}
 
+	return count >= 2
+}
+
+// checkLimits counts the node and stops the search if it ran out of time or nodes
+func (pv *pvSearch) checkLimits() bool {
+	pv.checkedNodes++
+
+	if pv.maxNodes > 0 && pv.checkedNodes >= pv.maxNodes {
+		pv.stopByTime = true
+	}
+
+	// a stop ends the search at once, the time is checked all 4096 nodes
+	if pv.control.stopped.Load() || pv.checkedNodes%4095 == 0 && pv.control.expired() {
+		pv.stopByTime = true
+	}
+
+	return pv.stopByTime
+}
+
+func (pv *pvSearch) quiescence(alpha, beta int) int {
+
+	if pv.checkLimits() {
+		return 0
+	}
+
+	if len(pv.pathLength) <= pv.board.ply {
+		return pv.evaluate()
+	}
 	pv.pathLength[pv.board.ply] = pv.board.ply
 
-	eval := Evaluate(pv.board)
+	eval := pv.evaluate()
 
 	if eval >= beta {
 		return beta
@@ -192,9 +495,12 @@ func (pv *pvSearch) quiescence(alpha, beta int) int {
 		alpha = eval
 	}
 
-	generator := Generator{board: pv.board}
+	generator := pv.generator()
 
-	for _, move := range generator.GenerateMoves() {
+	moves := generator.GenerateMovesInto(&pv.moves[pv.board.ply])
+	pv.sortCaptures(moves)
+
+	for _, move := range moves {
 
 		// only check capture moves
 		// TODO: should be optimized from the generator!
@@ -212,7 +518,7 @@ func (pv *pvSearch) quiescence(alpha, beta int) int {
 			alpha = score
 
 			// store new, better alpha node in the path
-			pv.path[pv.board.ply][pv.board.ply] = move
+			pv.path[pv.board.ply][pv.board.ply] = move.Pack()
 			for j := pv.board.ply + 1; j < pv.pathLength[pv.board.ply+1]; j++ {
 				pv.path[pv.board.ply][j] = pv.path[pv.board.ply+1][j]
 			}
@@ -223,10 +529,70 @@ func (pv *pvSearch) quiescence(alpha, beta int) int {
 	return alpha
 }
 
+// evaluate scores the board for the side to move. A known win counts the plies from the
+// root to the last pawn move or capture like a mate counts the plies to the mate, otherwise
+// waiting with the conversion would score the same as converting at once.
+func (pv *pvSearch) evaluate() int {
+	score := pv.evaluator.Evaluate(pv.board)
+	converted := pv.board.ply - pv.board.halfMoveClock
+	if converted <= 0 {
+		return score
+	}
+	if score >= pv.params.KnownWin {
+		return score - converted*evalConvertDelay
+	}
+	if score <= -pv.params.KnownWin {
+		return score + converted*evalConvertDelay
+	}
+	return score
+}
+
+// principalVariation unpacks the path of the root by playing it on the board
+func (pv *pvSearch) principalVariation() []Move {
+	line := make([]Move, pv.pathLength[0])
+	for i := range line {
+		line[i] = pv.board.UnpackMove(pv.path[0][i])
+		pv.board.MakeMove(line[i])
+	}
+	for range line {
+		pv.board.UndoMove()
+	}
+	return line
+}
+
+// generator returns the generator of the current ply, it keeps its scratch between nodes
+func (pv *pvSearch) generator() *Generator {
+	generator := &pv.generators[pv.board.ply]
+	generator.board = pv.board
+	return generator
+}
+
+// sortCaptures orders the captures in front of the other moves by the material they win
+// in the static exchange. The sort is stable, so equal exchanges and the other moves keep
+// the order of the generator.
+func (pv *pvSearch) sortCaptures(moves []Move) {
+	var gains [maxMoves]int
+	for i, move := range moves {
+		gains[i] = -searchEvalStart
+		if move.Content != Empty {
+			gains[i] = pv.board.staticExchange(move)
+		}
+	}
+
+	for i := 1; i < len(moves); i++ {
+		move, gain := moves[i], gains[i]
+		j := i
+		for ; j > 0 && gains[j-1] < gain; j-- {
+			moves[j], gains[j] = moves[j-1], gains[j-1]
+		}
+		moves[j], gains[j] = move, gain
+	}
+}
+
 func (pv *pvSearch) sortPv(moves []Move) []Move {
 	pv.followPv = false
 	for i := 0; i < len(moves); i++ {
-		if moves[i] == pv.path[0][pv.board.ply] {
+		if moves[i].Pack() == pv.path[0][pv.board.ply] {
 			pv.followPv = true
 			tmp := moves[0]
 			moves[0] = moves[i]
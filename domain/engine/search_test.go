@@ -20,6 +20,267 @@ func TestQueenPromotion(t *testing.T) {
 	doTestBestMoveForFEN("7k/P7/8/8/8/8/8/K7 w - - 1 0", e, t)
 }
 
+func TestKnownWinPrefersConvertingAtOnce(t *testing.T) {
+	scoreAfter := func(moves ...string) int {
+		b := NewBoard("7k/P7/8/8/8/8/8/K7 w - - 1 0")
+		b.ply = 0
+		for _, str := range moves {
+			m, err := b.LegalMove(str)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b.MakeMove(m)
+		}
+		evaluator := newHandcraftedEvaluator(nil)
+		pv := pvSearch{board: b, evaluator: evaluator, params: evaluator.params}
+		return pv.evaluate()
+	}
+
+	// both lines reach the same position, the first one promotes two plies earlier
+	now := scoreAfter("a7a8q", "h8g7", "a1b2", "g7f6")
+	later := scoreAfter("a1b2", "h8g7", "a7a8q", "g7f6")
+
+	if now <= later {
+		t.Errorf("Expected promoting at once to score above %d but found %d\n", later, now)
+	}
+}
+
+func TestMultiPVReturnsDistinctRankedLines(t *testing.T) {
+	b := NewBoard("k7/P7/1Q6/8/8/8/8/K7 w - - 1 0")
+
+	searchVerbose = false
+	searchMaxTime = 100 * time.Millisecond
+
+	lines := SearchLines(b, SearchOptions{MultiPV: 3})
+
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines but found %d\n", len(lines))
+	}
+
+	if lines[0].Move.From != B6 || lines[0].Move.To != B8 || lines[0].Score < scoreMate {
+		t.Errorf("Expected first line to mate with b6b8 but found %s (%d)\n", lines[0].Move.String(), lines[0].Score)
+	}
+
+	for i := 1; i < len(lines); i++ {
+		if lines[i].Score > lines[i-1].Score {
+			t.Errorf("Expected line %d to score at most %d but found %d\n", i+1, lines[i-1].Score, lines[i].Score)
+		}
+		for j := 0; j < i; j++ {
+			if lines[i].Move == lines[j].Move {
+				t.Errorf("Expected distinct root moves but %s was found twice\n", lines[i].Move.String())
+			}
+		}
+	}
+}
+
+func TestSearchMovesRestrictsRootMoves(t *testing.T) {
+	b := NewBoard(defaultFEN)
+
+	searchVerbose = false
+	searchMaxTime = 100 * time.Millisecond
+
+	options, err := parseGo(b, []string{"go", "searchmoves", "a2a3", "h2h3"}, SearchOptions{MultiPV: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := SearchLines(b, options)
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines but found %d\n", len(lines))
+	}
+
+	for _, line := range lines {
+		if line.Move.From != A2 && line.Move.From != H2 {
+			t.Errorf("Expected only a2a3 or h2h3 but found %s\n", line.Move.String())
+		}
+	}
+}
+
+func TestSearchDepthAndNodes(t *testing.T) {
+	b := NewBoard(position2FEN)
+
+	searchVerbose = false
+	searchMaxTime = 100 * time.Millisecond
+
+	options, err := parseGo(b, []string{"go", "depth", "2"}, SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := SearchLines(b, options); len(lines) != 1 || lines[0].Depth != 2 {
+		t.Errorf("Expected one line of depth 2 but found %v\n", lines)
+	}
+
+	options, err = parseGo(b, []string{"go", "nodes", "500"}, SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := SearchLines(b, options); len(lines) != 1 || lines[0].Depth > 2 {
+		t.Errorf("Expected a shallow line after 500 nodes but found %v\n", lines)
+	}
+
+	for _, words := range [][]string{{"go", "depth", "0"}, {"go", "nodes", "x"}} {
+		if _, err := parseGo(b, words, SearchOptions{}); err == nil {
+			t.Errorf("Expected %v to be rejected\n", words)
+		}
+	}
+}
+
+func TestMateSearchStopsWithinMoves(t *testing.T) {
+	b := NewBoard("r3k3/2R5/4p2p/4Pp1P/8/5KR1/8/8 w - - 16 70")
+
+	lines := SearchLines(b, SearchOptions{MultiPV: 2, Mate: 1})
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines but found %d\n", len(lines))
+	}
+
+	if lines[0].Move.To != G8 || matePlies(lines[0].Score) != 1 {
+		t.Errorf("Expected mate in one with g3g8 but found %s (%s)\n", lines[0].Move.String(), formatUciScore(lines[0].Score))
+	}
+
+	// the mate is unique
+	if lines[1].Score >= scoreMate || lines[1].Depth != 2 {
+		t.Errorf("Expected no second mate in one but found %s (%s) at depth %d\n",
+			lines[1].Move.String(), formatUciScore(lines[1].Score), lines[1].Depth)
+	}
+}
+
+func TestAllMatesWithinPlies(t *testing.T) {
+	mateInTwo := []SearchLine{{Score: matedScore(3)}, {Score: -matedScore(2)}}
+
+	if !allMates(mateInTwo, 3) {
+		t.Error("Expected both lines to mate within 3 plies")
+	}
+	// a mate in two found by a check extension does not answer a search for a mate in one
+	if allMates(mateInTwo, 1) {
+		t.Error("Expected the mate in 3 plies to be longer than 1 ply")
+	}
+	if allMates([]SearchLine{{Score: matedScore(1)}, {Score: 50}}, searchMaxPly) {
+		t.Error("Expected a line without a mate")
+	}
+}
+
+func TestPonderSearchKeepsResultUntilPonderHit(t *testing.T) {
+	b := NewBoard("k7/P7/1Q6/8/8/8/8/K7 w - - 1 0")
+
+	verbose := searchVerbose
+	t.Cleanup(func() { searchVerbose = verbose })
+	searchVerbose = false
+
+	clock := &simulatedClock{step: time.Millisecond}
+	job := newSearchJob(b, SearchOptions{MultiPV: 1, Ponder: true}, clock)
+
+	// the mate is found quickly but must not be reported before the ponder hit,
+	// however long the search waits for it
+	<-job.done
+	clock.now = clock.now.Add(2 * searchMaxTime)
+	if !job.Pondering() {
+		t.Fatalf("Expected job to wait for a ponder hit\n")
+	}
+
+	if !job.Searches(b) {
+		t.Errorf("Expected job to search the given position\n")
+	}
+
+	job.PonderHit()
+	lines := job.Wait()
+
+	if len(lines) == 0 || lines[0].Move.To != B8 {
+		t.Errorf("Expected b6b8 after ponder hit but found %v\n", lines)
+	}
+}
+
+func TestSearchJobComparesHistory(t *testing.T) {
+	play := func(moves ...string) *Board {
+		b := NewBoard(defaultFEN)
+		for _, str := range moves {
+			m, err := b.LegalMove(str)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b.MakeMove(m)
+		}
+		return b
+	}
+
+	b := play("e2e4", "e7e5", "g1f3", "g8f6", "f1c4", "f8c5")
+	job := newSearchJob(b, SearchOptions{Ponder: true, Infinite: true}, systemClock{})
+	t.Cleanup(func() {
+		job.Stop()
+		job.Wait()
+	})
+
+	// the same position by other moves has another history
+	transposed := play("e2e4", "e7e5", "f1c4", "f8c5", "g1f3", "g8f6")
+	if transposed.FEN() != b.FEN() || transposed.Hash() != b.Hash() {
+		t.Fatal("Expected the moves to transpose")
+	}
+
+	if !job.Searches(b.Clone()) {
+		t.Error("Expected job to search the position reached by its moves")
+	}
+	if job.Searches(transposed) {
+		t.Error("Expected job not to search the position reached by other moves")
+	}
+}
+
+func TestStoppedSearchReturnsLegalMove(t *testing.T) {
+	b := NewBoard(position2FEN)
+	options := SearchOptions{MultiPV: 2, SearchMoves: []Move{{From: E2, To: A6}}}
+
+	control := newSearchControl(systemClock{})
+	control.stopped.Store(true)
+	lines := searchLines(b, options, control, newTimeManager(b, options))
+
+	if len(lines) != 1 || lines[0].Move.From != E2 || lines[0].Move.To != A6 || !b.IsLegal(lines[0].Move) {
+		t.Errorf("Expected the searched move e2a6 but found %v\n", lines)
+	}
+}
+
+func TestDrawScoreUsesContemptOfEngine(t *testing.T) {
+	pv := pvSearch{board: NewBoard(defaultFEN), rootSide: White, contempt: 30}
+
+	if score := pv.drawScore(); score != -30 {
+		t.Errorf("Expected draw to score -30 for the engine but found %d\n", score)
+	}
+
+	pv.board.MakeMove(Move{From: E2, To: E4, MovedPiece: WhitePawn})
+	if score := pv.drawScore(); score != 30 {
+		t.Errorf("Expected draw to score 30 for the opponent but found %d\n", score)
+	}
+}
+
+func TestRepetitionInsideSearchIsDraw(t *testing.T) {
+	b := NewBoard(defaultFEN)
+	knightTour := []Move{
+		{From: G1, To: F3, MovedPiece: WhiteKnight},
+		{From: G8, To: F6, MovedPiece: BlackKnight},
+		{From: F3, To: G1, MovedPiece: WhiteKnight},
+		{From: F6, To: G8, MovedPiece: BlackKnight},
+	}
+
+	for _, move := range knightTour {
+		b.MakeMove(move)
+	}
+
+	if pv := (pvSearch{board: b, rootHistory: 0}); !pv.isRepetition() {
+		t.Errorf("Expected a repetition inside the search to be a draw\n")
+	}
+
+	if pv := (pvSearch{board: b, rootHistory: len(b.history)}); pv.isRepetition() {
+		t.Errorf("Expected a single repetition before the search not to be a draw\n")
+	}
+
+	for _, move := range knightTour {
+		b.MakeMove(move)
+	}
+
+	if pv := (pvSearch{board: b, rootHistory: len(b.history)}); !pv.isRepetition() {
+		t.Errorf("Expected a threefold repetition to be a draw\n")
+	}
+}
+
 func doTestBestMoveForFEN(fen string, e Move, t *testing.T) {
 	b := NewBoard(fen)
 
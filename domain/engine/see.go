@@ -0,0 +1,61 @@
+package engine
+
+import "math/bits"
+
+// seeValues are the piece values of the static exchange evaluation
+var seeValues = [King + 1]int{Pawn: pawnValue, Knight: knightValue, Bishop: bishopValue, Rook: rookValue, Queen: queenValue, King: kingValue}
+
+// staticExchange returns the material the side to move wins by the capture sequence
+// on the target square of a move, both sides recapturing with their least valuable
+// piece as long as it pays. Sliders behind a capturing piece join by the occupancy.
+func (b *Board) staticExchange(m Move) int {
+	bb := &b.bitboards
+	to := to64(int8(m.To))
+	occupied := bb.occupied() &^ bit(int8(m.From))
+
+	var gain [32]int
+	gain[0] = seeValues[abs(m.Content)]
+	attacker := abs(m.MovedPiece)
+
+	switch m.Special {
+	case moveEnPassant:
+		gain[0] = seeValues[Pawn]
+		occupied &^= bit(int8(m.To) - m.MovedPiece*nextRank)
+	case movePromotion:
+		gain[0] += seeValues[abs(m.Promoted)] - seeValues[Pawn]
+		attacker = abs(m.Promoted)
+	}
+
+	side := 1
+	if m.MovedPiece < 0 {
+		side = 0
+	}
+
+	depth := 0
+	for {
+		attackers := bb.attackersTo(to, side, occupied) & occupied
+		if attackers == 0 {
+			break
+		}
+
+		depth++
+		gain[depth] = seeValues[attacker] - gain[depth-1]
+		// the side stops capturing when it loses either way
+		if gain[depth-1] > 0 && gain[depth] < 0 {
+			depth--
+			break
+		}
+
+		for attacker = Pawn; bb.pieces[side][attacker]&attackers == 0; attacker++ {
+		}
+		occupied &^= uint64(1) << bits.TrailingZeros64(bb.pieces[side][attacker]&attackers)
+		side = 1 - side
+	}
+
+	for ; depth > 0; depth-- {
+		if -gain[depth] < gain[depth-1] {
+			gain[depth-1] = -gain[depth]
+		}
+	}
+	return gain[0]
+}
@@ -0,0 +1,44 @@
+package engine
+
+import "testing"
+
+func TestStaticExchange(t *testing.T) {
+	tests := []struct {
+		fen      string
+		move     string
+		expected int
+	}{
+		// undefended pawn
+		{"1k1r4/1pp4p/p7/4p3/8/P5P1/1PP4P/2K1R3 w - - 0 1", "e1e5", pawnValue},
+		// defended pawn taken by the knight, which is lost in the exchange
+		{"1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1", "d3e5", pawnValue - knightValue},
+		// the rook behind the rook joins the exchange
+		{"4k3/4r3/8/4p3/8/8/4R3/4R1K1 w - - 0 1", "e2e5", pawnValue},
+		{"4k3/4r3/4r3/4p3/8/8/4R3/4K3 w - - 0 1", "e2e5", pawnValue - rookValue},
+		// en passant
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", pawnValue},
+	}
+
+	for _, test := range tests {
+		b := NewBoard(test.fen)
+		move, err := b.LegalMove(test.move)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found := b.staticExchange(move); found != test.expected {
+			t.Errorf("Expected %d for %s in %s but found %d\n", test.expected, test.move, test.fen, found)
+		}
+	}
+}
+
+func TestSortCapturesByStaticExchange(t *testing.T) {
+	// the queen takes the defended knight, the pawn wins it
+	b := NewBoard("4k3/8/3p4/4p3/3n4/2P5/8/3QK3 w - - 0 1")
+	pv := pvSearch{board: b}
+	moves := NewGenerator(b).GenerateMoves()
+	pv.sortCaptures(moves)
+
+	if moves[0].String() != "c3xd4" || moves[1].String() != "d1xd4" {
+		t.Errorf("Expected c3xd4 before d1xd4 but found %s and %s\n", moves[0].String(), moves[1].String())
+	}
+}
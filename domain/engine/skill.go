@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"math/rand"
+	"time"
+)
+
+const (
+	// SkillLevelMax plays at full strength
+	SkillLevelMax = 20
+
+	skillMultiPV = 4
+	skillEloMin  = 1350
+	skillEloMax  = 2850
+)
+
+// Skill weakens the play of a search by limiting depth and nodes and by
+// choosing randomly among the best lines, weighted by their score gap
+type Skill struct {
+	// Level from 0 (weakest) up to SkillLevelMax
+	Level int
+	// Seed of the random choice, zero seeds from the current time
+	Seed int64
+}
+
+// skillLevelFromElo maps a UCI_Elo value onto a skill level
+func skillLevelFromElo(elo int) int {
+	if elo <= skillEloMin {
+		return 0
+	}
+	if elo >= skillEloMax {
+		return SkillLevelMax
+	}
+	return (elo - skillEloMin) * SkillLevelMax / (skillEloMax - skillEloMin)
+}
+
+func (s *Skill) maxDepth() int {
+	return s.Level/2 + 1
+}
+
+func (s *Skill) maxNodes() int64 {
+	return 1000 << uint(s.Level/2)
+}
+
+// pick chooses one of the lines and returns it first, followed by the remaining lines.
+// The weaker the level, the more likely a line with a lower score is played.
+func (s *Skill) pick(lines []SearchLine, multiPV int) []SearchLine {
+	seed := s.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	weakness := 120 - 2*s.Level
+	top := lines[0].Score
+
+	delta := top - lines[len(lines)-1].Score
+	if delta > pawnValue {
+		delta = pawnValue
+	}
+
+	chosen := 0
+	maxScore := -searchEvalStart
+	for i, line := range lines {
+		push := (weakness*(top-line.Score) + delta*rng.Intn(weakness)) / 128
+		if line.Score+push >= maxScore {
+			maxScore = line.Score + push
+			chosen = i
+		}
+	}
+
+	picked := make([]SearchLine, 0, len(lines))
+	picked = append(picked, lines[chosen])
+	picked = append(picked, lines[:chosen]...)
+	picked = append(picked, lines[chosen+1:]...)
+
+	if len(picked) > multiPV {
+		picked = picked[:multiPV]
+	}
+
+	return picked
+}
@@ -0,0 +1,75 @@
+package engine
+
+import "testing"
+
+func TestSkillIsDeterministicUnderSeed(t *testing.T) {
+	b := NewBoard(defaultFEN)
+	options := SearchOptions{MultiPV: 1, Skill: &Skill{Level: 0, Seed: 4711}}
+
+	first := SearchLines(b, options)
+	second := SearchLines(b, options)
+
+	if len(first) != 1 || len(second) != 1 {
+		t.Fatalf("Expected a single line but found %d and %d\n", len(first), len(second))
+	}
+
+	if first[0].Move != second[0].Move {
+		t.Errorf("Expected the same move for the same seed but found %s and %s\n",
+			first[0].Move.String(), second[0].Move.String())
+	}
+}
+
+func TestSkillWeakLevelPlaysWorseMoves(t *testing.T) {
+	lines := skillTestLines()
+	weaker := 0
+
+	for seed := int64(1); seed <= 100; seed++ {
+		skill := Skill{Level: 0, Seed: seed}
+		if skill.pick(lines, 1)[0].Move != lines[0].Move {
+			weaker++
+		}
+	}
+
+	if weaker == 0 {
+		t.Errorf("Expected level 0 to play a weaker move at least once\n")
+	}
+}
+
+func TestSkillStrongLevelAvoidsBlunders(t *testing.T) {
+	lines := skillTestLines()
+
+	for seed := int64(1); seed <= 100; seed++ {
+		skill := Skill{Level: SkillLevelMax - 1, Seed: seed}
+		if picked := skill.pick(lines, 1)[0]; picked.Score < 0 {
+			t.Fatalf("Expected no blunder but level %d played %s for seed %d\n", skill.Level, picked.Move.String(), seed)
+		}
+	}
+}
+
+func TestSkillLevelFromElo(t *testing.T) {
+	for elo, level := range map[int]int{1000: 0, skillEloMin: 0, 2100: 10, skillEloMax: SkillLevelMax, 3200: SkillLevelMax} {
+		if actual := skillLevelFromElo(elo); actual != level {
+			t.Errorf("Expected level %d for elo %d but found %d\n", level, elo, actual)
+		}
+	}
+}
+
+func TestSkillFinishesFirstDepthInRichPosition(t *testing.T) {
+	b := NewBoard(position2FEN)
+
+	for level := 0; level < SkillLevelMax; level += 4 {
+		options := SearchOptions{MultiPV: 1, Skill: &Skill{Level: level, Seed: 4711}}
+		if lines := SearchLines(b, options); len(lines) != 1 {
+			t.Errorf("Expected a move at level %d but found %d lines\n", level, len(lines))
+		}
+	}
+}
+
+func skillTestLines() []SearchLine {
+	return []SearchLine{
+		{Move: Move{From: E2, To: E4}, Score: 100},
+		{Move: Move{From: D2, To: D4}, Score: 90},
+		{Move: Move{From: G1, To: F3}, Score: 80},
+		{Move: Move{From: F2, To: F3}, Score: -500},
+	}
+}
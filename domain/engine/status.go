@@ -0,0 +1,69 @@
+package engine
+
+import "math/bits"
+
+var statusNames = []string{
+	statusNormal:     "normal",
+	statusCheck:      "check",
+	statusWhiteMates: "white_mates",
+	statusBlackMates: "black_mates",
+	statusStaleMate:  "stalemate",
+	statusDraw:       "draw",
+	statusWhiteWins:  "white_wins",
+	statusBlackWins:  "black_wins",
+}
+
+// gameStatus computes the state of the game from the position and its history
+func (b *Board) gameStatus() int {
+	moves := NewGenerator(b).GenerateMoves()
+	inCheck := b.InCheck()
+
+	switch {
+	case len(moves) == 0 && inCheck && b.sideToMove == White:
+		return statusBlackMates
+	case len(moves) == 0 && inCheck:
+		return statusWhiteMates
+	case len(moves) == 0:
+		return statusStaleMate
+	case b.halfMoveClock >= 100 || b.repetitions() >= 2 || b.insufficientMaterial():
+		return statusDraw
+	case inCheck:
+		return statusCheck
+	}
+	return statusNormal
+}
+
+// repetitions counts the earlier occurrences of the position since the last capture or
+// pawn move
+func (b *Board) repetitions() int {
+	first := len(b.history) - b.halfMoveClock
+	if first < 0 {
+		first = 0
+	}
+
+	count := 0
+	for i := first; i < len(b.history); i++ {
+		if b.history[i].hash == b.currentHash {
+			count++
+		}
+	}
+	return count
+}
+
+// insufficientMaterial checks whether neither side has more than a single minor piece
+func (b *Board) insufficientMaterial() bool {
+	minors := 0
+	for side := range b.bitboards.pieces {
+		pieces := &b.bitboards.pieces[side]
+		if pieces[Pawn]|pieces[Rook]|pieces[Queen] != 0 {
+			return false
+		}
+		minors += bits.OnesCount64(pieces[Knight] | pieces[Bishop])
+	}
+	return minors <= 1
+}
+
+// gameOver checks whether the status ends the game
+func gameOver(status int) bool {
+	return status != statusNormal && status != statusCheck
+}
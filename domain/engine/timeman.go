@@ -0,0 +1,132 @@
+package engine
+
+import "time"
+
+const (
+	tmDefaultMovesToGo = 30
+	tmMaxMovesToGo     = 50
+	tmMinimumTime      = 10 * time.Millisecond
+	tmHardFactor       = 4
+	tmFailLowMargin    = 30
+	tmFailLowFactor    = 1.5
+	tmUnstableFactor   = 1.4
+)
+
+// TimeControl is the clock situation given by a "go" command
+type TimeControl struct {
+	WhiteTime time.Duration
+	BlackTime time.Duration
+	WhiteInc  time.Duration
+	BlackInc  time.Duration
+	MovesToGo int
+	MoveTime  time.Duration
+}
+
+// clock is the time source of a search, tests replace it with simulated time
+type clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// timeManager allocates the time of a single search.
+// The soft limit decides whether a new iteration is started, the hard limit stops the search.
+type timeManager struct {
+	limited  bool
+	soft     time.Duration
+	hard     time.Duration
+	previous SearchLine
+	searched bool
+}
+
+func newTimeManager(board *Board, options SearchOptions) *timeManager {
+	tm := &timeManager{limited: true}
+	tc := options.Clock
+
+	remaining, inc := tc.WhiteTime, tc.WhiteInc
+	if board.sideToMove == Black {
+		remaining, inc = tc.BlackTime, tc.BlackInc
+	}
+
+	switch {
+	case options.Mate > 0 || options.Infinite:
+		tm.limited = false
+
+	case tc.MoveTime > 0:
+		tm.soft = maxDuration(tc.MoveTime-options.MoveOverhead, tmMinimumTime)
+		tm.hard = tm.soft
+
+	case remaining > 0:
+		movesToGo := tc.MovesToGo
+		if movesToGo <= 0 || movesToGo > tmMaxMovesToGo {
+			movesToGo = tmDefaultMovesToGo
+		}
+
+		timeLeft := maxDuration(remaining-options.MoveOverhead, tmMinimumTime)
+
+		// keep a reserve for the following moves unless this is the last one before the time control
+		maxHard := timeLeft
+		if movesToGo > 1 {
+			maxHard = timeLeft * 3 / 4
+		}
+
+		tm.soft = timeLeft/time.Duration(movesToGo) + inc
+		tm.hard = minDuration(tm.soft*tmHardFactor, maxHard)
+		tm.soft = minDuration(tm.soft, tm.hard)
+
+	case options.Depth > 0 || options.Nodes > 0:
+		tm.limited = false
+
+	default:
+		tm.soft = searchMaxTime
+		tm.hard = searchMaxTime
+	}
+
+	return tm
+}
+
+// stopAfterIteration decides whether the search should stop after a completed iteration.
+// The soft limit is extended on a fail low or a changed best move, a forced move stops at once.
+func (tm *timeManager) stopAfterIteration(elapsed time.Duration, best SearchLine, rootMoves int) bool {
+	if !tm.limited {
+		return false
+	}
+
+	if rootMoves == 1 {
+		return true
+	}
+
+	scale := 1.0
+	if tm.searched {
+		if best.Score < tm.previous.Score-tmFailLowMargin {
+			scale *= tmFailLowFactor
+		}
+		if best.Move != tm.previous.Move {
+			scale *= tmUnstableFactor
+		}
+	}
+
+	tm.previous = best
+	tm.searched = true
+
+	limit := minDuration(time.Duration(float64(tm.soft)*scale), tm.hard)
+	return elapsed >= limit
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+// simulatedClock advances by a fixed step every time it is read
+type simulatedClock struct {
+	now  time.Time
+	step time.Duration
+}
+
+func (c *simulatedClock) Now() time.Time {
+	c.now = c.now.Add(c.step)
+	return c.now
+}
+
+func TestTimeManagerAllocatesFromRemainingTime(t *testing.T) {
+	options := SearchOptions{Clock: TimeControl{WhiteTime: 60 * time.Second, BlackTime: time.Second}}
+	doTestTimeAllocation(defaultFEN, options, 2*time.Second, 8*time.Second, t)
+}
+
+func TestTimeManagerUsesClockOfSideToMove(t *testing.T) {
+	options := SearchOptions{Clock: TimeControl{WhiteTime: time.Second, BlackTime: 30 * time.Second, BlackInc: time.Second}}
+	doTestTimeAllocation("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", options, 2*time.Second, 8*time.Second, t)
+}
+
+func TestTimeManagerKeepsMoveOverheadForLastMove(t *testing.T) {
+	options := SearchOptions{Clock: TimeControl{WhiteTime: time.Second, MovesToGo: 1}, MoveOverhead: 100 * time.Millisecond}
+	doTestTimeAllocation(defaultFEN, options, 900*time.Millisecond, 900*time.Millisecond, t)
+}
+
+func TestTimeManagerKeepsReserveForFollowingMoves(t *testing.T) {
+	options := SearchOptions{Clock: TimeControl{WhiteTime: 4 * time.Second, MovesToGo: 2}}
+	doTestTimeAllocation(defaultFEN, options, 2*time.Second, 3*time.Second, t)
+}
+
+func TestTimeManagerUsesMoveTime(t *testing.T) {
+	options := SearchOptions{Clock: TimeControl{WhiteTime: time.Minute, MoveTime: 500 * time.Millisecond}, MoveOverhead: 50 * time.Millisecond}
+	doTestTimeAllocation(defaultFEN, options, 450*time.Millisecond, 450*time.Millisecond, t)
+}
+
+func TestTimeManagerExtendsOnFailLow(t *testing.T) {
+	tm := &timeManager{limited: true, soft: time.Second, hard: 4 * time.Second}
+	a := Move{From: E2, To: E4}
+
+	if tm.stopAfterIteration(500*time.Millisecond, SearchLine{Move: a, Score: 50}, 20) {
+		t.Errorf("Expected search to continue before the soft limit\n")
+	}
+	if tm.stopAfterIteration(1200*time.Millisecond, SearchLine{Move: a, Score: 0}, 20) {
+		t.Errorf("Expected search to continue after a fail low\n")
+	}
+	if !tm.stopAfterIteration(1300*time.Millisecond, SearchLine{Move: a, Score: 0}, 20) {
+		t.Errorf("Expected search to stop once the score is stable\n")
+	}
+}
+
+func TestTimeManagerExtendsOnUnstableBestMove(t *testing.T) {
+	tm := &timeManager{limited: true, soft: time.Second, hard: 4 * time.Second}
+
+	tm.stopAfterIteration(500*time.Millisecond, SearchLine{Move: Move{From: E2, To: E4}}, 20)
+	if tm.stopAfterIteration(1200*time.Millisecond, SearchLine{Move: Move{From: D2, To: D4}}, 20) {
+		t.Errorf("Expected search to continue after the best move changed\n")
+	}
+}
+
+func TestTimeManagerNeverExceedsHardLimit(t *testing.T) {
+	tm := &timeManager{limited: true, soft: time.Second, hard: 1100 * time.Millisecond}
+
+	tm.stopAfterIteration(500*time.Millisecond, SearchLine{Move: Move{From: E2, To: E4}, Score: 100}, 20)
+	if !tm.stopAfterIteration(1100*time.Millisecond, SearchLine{Move: Move{From: D2, To: D4}, Score: -100}, 20) {
+		t.Errorf("Expected search to stop at the hard limit\n")
+	}
+}
+
+func TestSearchStopsAtOnceForForcedMove(t *testing.T) {
+	b := NewBoard("7k/8/8/8/8/8/8/K5R1 b - - 0 1")
+	options := SearchOptions{MultiPV: 1, Clock: TimeControl{BlackTime: time.Minute}}
+
+	c := &simulatedClock{step: time.Millisecond}
+	lines := searchLines(b, options, newSearchControl(c), newTimeManager(b, options))
+
+	if len(lines) != 1 || lines[0].Depth != 1 {
+		t.Errorf("Expected a single line of depth 1 but found %v\n", lines)
+	}
+}
+
+func TestSearchStopsWithinHardLimit(t *testing.T) {
+	b := NewBoard(defaultFEN)
+	options := SearchOptions{MultiPV: 1, Clock: TimeControl{WhiteTime: 300 * time.Millisecond}}
+
+	c := &simulatedClock{step: time.Millisecond}
+	control := newSearchControl(c)
+	tm := newTimeManager(b, options)
+	lines := searchLines(b, options, control, tm)
+
+	if len(lines) == 0 {
+		t.Fatalf("Expected a result within the time limit\n")
+	}
+
+	// reading the elapsed time advances the clock once more
+	if elapsed := control.elapsed(); elapsed > tm.hard+2*c.step {
+		t.Errorf("Expected search to take at most %s but it took %s\n", tm.hard, elapsed)
+	}
+}
+
+func doTestTimeAllocation(fen string, options SearchOptions, soft, hard time.Duration, t *testing.T) {
+	tm := newTimeManager(NewBoard(fen), options)
+
+	if tm.soft != soft || tm.hard != hard {
+		t.Errorf("Expected soft %s and hard %s limits but found %s and %s\n", soft, hard, tm.soft, tm.hard)
+	}
+}
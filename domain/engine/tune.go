@@ -0,0 +1,191 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	tuneMaxIterations = 100
+	tuneStep          = 1
+	tuneDefaultOutput = "params.json"
+)
+
+// TuningPosition is a quiet position labelled with the result of its game for White
+type TuningPosition struct {
+	board  *Board
+	result float64
+}
+
+// LoadTuningPositions reads an EPD or FEN file with one position and its game result per line.
+// Every position is resolved to the quiet end of its quiescence search.
+func LoadTuningPositions(path string) ([]TuningPosition, error) {
+	input, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	positions := []TuningPosition{}
+	scanner := bufio.NewScanner(input)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fen, result, err := parseTuningLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		if _, err := parseFEN(fen); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+
+		positions = append(positions, TuningPosition{board: resolveQuiet(NewBoard(fen)), result: result})
+	}
+
+	return positions, scanner.Err()
+}
+
+// parseTuningLine splits a line into its position and the result for White. The result is
+// given as EPD opcode (c9 "1-0";), in brackets ([0.5] or [1/2-1/2]) or as last field.
+func parseTuningLine(line string) (string, float64, error) {
+	fen, result := "", ""
+
+	if i := strings.Index(line, "c9"); i >= 0 {
+		fen, result = line[:i], strings.Trim(line[i+2:], " \";")
+	} else if i := strings.Index(line, "["); i >= 0 {
+		fen, result = line[:i], strings.Trim(line[i:], " []")
+	} else if i := strings.LastIndex(line, " "); i >= 0 {
+		fen, result = line[:i], line[i+1:]
+	}
+
+	fen = strings.TrimSpace(fen)
+	fen = strings.TrimSuffix(fen, ";")
+
+	switch result {
+	case "1-0":
+		return fen, 1, nil
+	case "0-1":
+		return fen, 0, nil
+	case "1/2-1/2":
+		return fen, 0.5, nil
+	}
+
+	value, err := strconv.ParseFloat(result, 64)
+	if err != nil || value < 0 || value > 1 {
+		return "", 0, fmt.Errorf("invalid result: %s", result)
+	}
+	return fen, value, nil
+}
+
+// resolveQuiet plays the principal variation of the quiescence search
+func resolveQuiet(b *Board) *Board {
+	pv := pvSearch{board: b, control: newSearchControl(systemClock{}), evaluator: &handcraftedEvaluator{params: defaultProfile()}, params: defaultProfile()}
+	pv.board.ply = 0
+	pv.quiescence(-searchEvalStart, searchEvalStart)
+
+	for i := 0; i < pv.pathLength[0]; i++ {
+		b.MakeMove(b.UnpackMove(pv.path[0][i]))
+	}
+	b.ply = 0
+
+	return b
+}
+
+// tuningError returns the mean squared error between the results and the winning
+// probabilities of the static evaluation of the quiet positions
+func tuningError(positions []TuningPosition, p *EvalParams, k float64) float64 {
+	sum := 0.0
+	for _, position := range positions {
+		score := evaluateTerms(position.board, nil, p, &evalTerms{}) * int(position.board.sideToMove)
+		diff := position.result - sigmoid(float64(score), k)
+		sum += diff * diff
+	}
+	return sum / float64(len(positions))
+}
+
+// sigmoid maps a score in centipawns onto a winning probability
+func sigmoid(score, k float64) float64 {
+	return 1 / (1 + math.Pow(10, -k*score/400))
+}
+
+// tuneScaling finds the scaling constant of the sigmoid which fits the current parameters best
+func tuneScaling(positions []TuningPosition, p *EvalParams) float64 {
+	best, bestError := 1.0, tuningError(positions, p, 1.0)
+
+	for step := 0.1; step >= 0.001; step /= 10 {
+		for improved := true; improved; {
+			improved = false
+			for _, k := range []float64{best - step, best + step} {
+				if k <= 0 {
+					continue
+				}
+				if e := tuningError(positions, p, k); e < bestError {
+					best, bestError, improved = k, e, true
+				}
+			}
+		}
+	}
+
+	return best
+}
+
+// Tune optimises the parameters by local search to minimise the error on the positions
+// and returns the tuned parameters with their error. Progress reports every iteration.
+func Tune(positions []TuningPosition, params EvalParams, iterations int, progress func(iteration int, err float64)) (EvalParams, float64, error) {
+	if len(positions) == 0 {
+		return params, 0, errors.New("no positions to tune")
+	}
+	if iterations < 1 || iterations > tuneMaxIterations {
+		iterations = tuneMaxIterations
+	}
+
+	// the tables are tuned in place, keep them apart from the given parameters
+	params = params.clone()
+
+	k := tuneScaling(positions, &params)
+	bestError := tuningError(positions, &params, k)
+	values := params.weights()
+
+	for iteration := 1; iteration <= iterations; iteration++ {
+		improved := false
+
+		for _, value := range values {
+			for _, step := range []int{tuneStep, -tuneStep} {
+				*value += step
+				if e := tuningError(positions, &params, k); e < bestError {
+					bestError, improved = e, true
+					break
+				}
+				*value -= step
+			}
+		}
+
+		if progress != nil {
+			progress(iteration, bestError)
+		}
+		if !improved {
+			break
+		}
+	}
+
+	return params, bestError, nil
+}
+
+// WriteEvalParams writes the parameters to a JSON file, which loads as profile
+func WriteEvalParams(path string, p EvalParams) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseTuningLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		fen    string
+		result float64
+	}{
+		{`rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - c9 "1-0";`, "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq -", 1},
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1 [0.5]", "4k3/8/8/8/8/8/8/4K3 w - - 0 1", 0.5},
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1 [1/2-1/2]", "4k3/8/8/8/8/8/8/4K3 w - - 0 1", 0.5},
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0-1", "4k3/8/8/8/8/8/8/4K3 w - -", 0},
+	}
+
+	for _, test := range tests {
+		fen, result, err := parseTuningLine(test.line)
+		if err != nil || fen != test.fen || result != test.result {
+			t.Errorf("Expected %q with %.1f but found %q with %.1f (%v)\n", test.fen, test.result, fen, result, err)
+		}
+	}
+
+	if _, _, err := parseTuningLine("4k3/8/8/8/8/8/8/4K3 w - - [2.0]"); err == nil {
+		t.Errorf("Expected an invalid result to be rejected\n")
+	}
+}
+
+func TestLoadTuningPositionsResolvesCaptures(t *testing.T) {
+	// the queen on d5 is taken, so the position is resolved to a quiet one without it
+	path := writeTuningFile(t, "4k3/8/8/3q4/4P3/8/8/4K3 w - - 0 1 [1.0]\n")
+
+	positions, err := LoadTuningPositions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(positions) != 1 || positions[0].board.data[D5] != WhitePawn {
+		t.Errorf("Expected the capture on d5 to be played\n%s\n", FormatBoard(positions[0].board))
+	}
+}
+
+func TestTuneReducesError(t *testing.T) {
+	path := writeTuningFile(t, ""+
+		"4k3/8/8/8/8/8/4P3/3NK3 w - - 0 1 [1.0]\n"+
+		"4k3/4p3/8/8/8/8/4P3/3NK3 w - - 0 1 [0.5]\n"+
+		"3nk3/4p3/8/8/8/8/8/4K3 w - - 0 1 [0.0]\n"+
+		"4k3/8/8/8/8/8/PPP5/4K3 w - - 0 1 [1.0]\n"+
+		"4k3/ppp5/8/8/8/8/PPP5/4K3 w - - 0 1 [0.5]\n")
+
+	positions, err := LoadTuningPositions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := defaultEvalParams
+	before := tuningError(positions, &params, tuneScaling(positions, &params))
+
+	tuned, after, err := Tune(positions, params, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after >= before || reflect.DeepEqual(tuned, defaultEvalParams) {
+		t.Errorf("Expected the error to shrink from %f but found %f\n", before, after)
+	}
+}
+
+func TestWeightsCoverListsAndFactors(t *testing.T) {
+	p := defaultEvalParams.clone()
+	values := map[*int]bool{}
+	for _, value := range p.weights() {
+		values[value] = true
+	}
+
+	for name, value := range map[string]*int{
+		"passedPawnEnd":       &p.PassedPawnEnd[6],
+		"passedPawnEnemyKing": &p.PassedPawnEnemyKing,
+		"kingAttackUnits":     &p.KingAttackUnits[Queen],
+		"kingAttackTable":     &p.KingAttackTable[20],
+		"pawnStorm":           &p.PawnStorm[2],
+		"pushPawn":            &p.PushPawn,
+		"scaleNoPawns":        &p.ScaleNoPawns,
+	} {
+		if !values[value] {
+			t.Errorf("Expected %s to be tuned\n", name)
+		}
+	}
+	if values[&p.KnownWin] || values[&p.MateSearchLevel] {
+		t.Error("Expected the known win and the mate search level to be kept")
+	}
+}
+
+func TestWriteEvalParams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "params.json")
+	if err := WriteEvalParams(path, defaultEvalParams); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var params EvalParams
+	if err := json.Unmarshal(data, &params); err != nil || !reflect.DeepEqual(params, defaultEvalParams) {
+		t.Errorf("Expected the written parameters to read back unchanged (%v)\n", err)
+	}
+}
+
+func writeTuningFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "positions.epd")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
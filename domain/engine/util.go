@@ -23,7 +23,7 @@ func printSearchLevel(pv *pvSearch, depth, score int, startTime time.Time) {
 	fmt.Printf("%3d %6s %6s %7s  ", depth,
 		formatScore(score), formatDuration(time.Since(startTime)), formatNodesCount(pv.checkedNodes))
 
-	for j := 0; j < pv.pathLength[0]; j++ {
+	for j, move := range pv.principalVariation() {
 		if pv.board.sideToMove == Black {
 			if j == 0 {
 				fmt.Printf("%d. ... ", pv.board.fullMoves)
@@ -37,7 +37,7 @@ func printSearchLevel(pv *pvSearch, depth, score int, startTime time.Time) {
 				fmt.Printf("%d. ", pv.board.fullMoves+(j/2))
 			}
 		}
-		fmt.Printf("%s ", pv.path[0][j].String())
+		fmt.Printf("%s ", move.String())
 	}
 	fmt.Printf("\n")
 }
@@ -112,6 +112,26 @@ func formatScore(score int) string {
 	return fmt.Sprintf("%.2f", float64(score)/100)
 }
 
+func formatUciScore(score int) string {
+	if score >= scoreMate {
+		return fmt.Sprintf("mate %d", (matePlies(score)+1)/2)
+	} else if score <= -scoreMate {
+		return fmt.Sprintf("mate -%d", (matePlies(score)+1)/2)
+	}
+	return fmt.Sprintf("cp %d", score)
+}
+
+// FormatEvalBreakdown formats the terms of an evaluation as a table of midgame and endgame scores
+func FormatEvalBreakdown(terms []EvalTerm) string {
+	str := fmt.Sprintf("%-16s %11s %11s %11s\n", "Term", "White", "Black", "Total")
+	for _, term := range terms {
+		str += fmt.Sprintf("%-16s %5d %5d %5d %5d %5d %5d\n", term.Name,
+			term.Middle[0], term.End[0], term.Middle[1], term.End[1],
+			term.Middle[0]-term.Middle[1], term.End[0]-term.End[1])
+	}
+	return str
+}
+
 func formatNodesCount(nodes int64) string {
 	if nodes < 1000 && nodes > -1000 {
 		return fmt.Sprintf("%d", nodes)
@@ -156,22 +176,7 @@ func FormatBoard(b *Board) string {
 			s += fmt.Sprintf("\t(%d) %s's move", b.fullMoves, color)
 		}
 		if r == 3 {
-			c := ""
-			if b.whiteCastle&castleShort != 0 {
-				c += "K"
-			}
-			if b.whiteCastle&castleLong != 0 {
-				c += "Q"
-			}
-			if b.blackCastle&castleShort != 0 {
-				c += "k"
-			}
-			if b.blackCastle&castleLong != 0 {
-				c += "q"
-			}
-			if len(c) == 0 {
-				c = "-"
-			}
+			c := b.CastlingRights()
 			s += fmt.Sprintf("\tCasteling: %s", c)
 		}
 		if r == 2 {
@@ -188,9 +193,8 @@ func FormatBoard(b *Board) string {
 	}
 	str += fmt.Sprintf("%s\t%s\t", files, lastMove)
 
-	switch b.status {
-	case statusCheck:
-		str += "Check!"
+	// a check is shown next to the board
+	switch b.gameStatus() {
 	case statusDraw:
 		str += "Draw!"
 	case statusWhiteMates:
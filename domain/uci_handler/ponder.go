@@ -0,0 +1,64 @@
+package uci
+
+import (
+	"sync"
+	"time"
+
+	"github.com/logantwalker/gopher-chess-api/domain/engine"
+)
+
+// abandoned sessions must not ponder forever
+const ponderTimeout = 5 * time.Minute
+
+var (
+	ponderMutex    sync.Mutex
+	ponderSessions = map[string]*engine.SearchJob{}
+)
+
+// searchWithPonder searches the board and converts the ponder search of the session on a hit
+func searchWithPonder(session string, board *engine.Board, options engine.SearchOptions) []engine.SearchLine {
+	if job := takePonder(session); job != nil {
+		if job.Searches(board) {
+			job.PonderHit()
+			return job.Wait()
+		}
+		job.Stop()
+	}
+
+	return engine.SearchLines(board, options)
+}
+
+// startPonder searches the expected position of the session in the background
+func startPonder(session string, board *engine.Board, options engine.SearchOptions) {
+	options.Ponder = true
+	job := engine.StartSearch(board, options)
+
+	ponderMutex.Lock()
+	if previous, ok := ponderSessions[session]; ok {
+		previous.Stop()
+	}
+	ponderSessions[session] = job
+	ponderMutex.Unlock()
+
+	time.AfterFunc(ponderTimeout, func() {
+		ponderMutex.Lock()
+		if ponderSessions[session] == job {
+			delete(ponderSessions, session)
+		}
+		ponderMutex.Unlock()
+		job.Stop()
+	})
+}
+
+func takePonder(session string) *engine.SearchJob {
+	if session == "" {
+		return nil
+	}
+
+	ponderMutex.Lock()
+	defer ponderMutex.Unlock()
+
+	job := ponderSessions[session]
+	delete(ponderSessions, session)
+	return job
+}
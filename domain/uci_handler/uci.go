@@ -1,6 +1,7 @@
 package uci
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -11,6 +12,21 @@ import (
 
 const defaultFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
 
+const (
+	// humanContempt keeps the engine from repeating moves in better positions against users
+	humanContempt = 20
+	// botDrawContempt is the contempt of the "avoid" and "accept" draw policies against bots
+	botDrawContempt = 50
+	maxContempt     = 100
+)
+
+var botDrawPolicies = map[string]int{
+	"":        0,
+	"neutral": 0,
+	"avoid":   botDrawContempt,
+	"accept":  -botDrawContempt,
+}
+
 
 func NewGame(c *gin.Context){
 	g := engine.NewGame()
@@ -43,26 +59,12 @@ func Command(c *gin.Context){
 			if len(words) > 2 && words[2] == "moves" {
 				g.Board = engine.NewBoard(defaultFEN)
 				for _, moveStr := range userCommand.Moves {
-					if m, err := engine.CreateMove(moveStr); err == nil {
-						gen := engine.NewGenerator(g.Board)
-						moves := gen.GenerateMoves()
-			
-						found := engine.Move{From: engine.Invalid}
-			
-						for _, move := range moves {
-							if move.From == m.From && move.To == m.To {
-								found = move
-								break
-							}
-						}
-						if found.From != engine.Invalid {
-							g.Board.MakeMove(found)
-						} else {
-							c.JSON(http.StatusBadRequest,gin.H{"error":"illegal move"})
-							return
-						}
-			
+					move, err := g.Board.LegalMove(moveStr)
+					if err != nil {
+						illegalMove(c, err)
+						return
 					}
+					g.Board.MakeMove(move)
 				}
 			}
 
@@ -73,31 +75,56 @@ func Command(c *gin.Context){
 	} else if words[0] == "go"{
 		g.Board = engine.NewBoard(defaultFEN)
 		for _, moveStr := range userCommand.Moves {
-			if m, err := engine.CreateMove(moveStr); err == nil {
-				gen := engine.NewGenerator(g.Board)
-				moves := gen.GenerateMoves()
-	
-				found := engine.Move{From: engine.Invalid}
-	
-				for _, move := range moves {
-					if move.From == m.From && move.To == m.To {
-						found = move
-						break
-					}
-				}
-				if found.From != engine.Invalid {
-					g.Board.MakeMove(found)
-				} else {
-					c.JSON(http.StatusBadRequest,gin.H{"error":"illegal move"})
-				}
-	
+			move, err := g.Board.LegalMove(moveStr)
+			if err != nil {
+				illegalMove(c, err)
+				return
 			}
+			g.Board.MakeMove(move)
+		}
+		if userCommand.Ponder && userCommand.Session == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error":"ponder requires a session"})
+			return
+		}
+		contempt, err := contemptFor(userCommand)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error":err.Error()})
+			return
+		}
+		params, ok := engine.EvalProfile(userCommand.EvalProfile)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error":"unknown eval_profile"})
+			return
+		}
+		options := engine.SearchOptions{MultiPV: userCommand.MultiPV, Contempt: contempt, Params: params}
+		if userCommand.Level != nil {
+			if *userCommand.Level < 0 || *userCommand.Level > engine.SkillLevelMax {
+				c.JSON(http.StatusBadRequest, gin.H{"error":"invalid level"})
+				return
+			}
+			if *userCommand.Level < engine.SkillLevelMax {
+				options.Skill = &engine.Skill{Level: *userCommand.Level}
+			}
+		}
+		lines := searchWithPonder(userCommand.Session, g.Board, options)
+		if len(lines) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error":"no legal moves"})
+			return
+		}
+		bestMove := lines[0].Move
+		g.Board.MakeMove(bestMove)
+		response := gin.H{"board":engine.FormatBoard(g.Board),"bestmove":bestMove.UciString()}
+		if userCommand.MultiPV > 0 {
+			response["lines"] = analysisLines(lines)
+		}
+		// keep searching the expected reply until the next request of the session
+		if userCommand.Ponder && len(lines[0].Pv) > 1 {
+			ponderMove := lines[0].Pv[1]
+			response["ponder"] = ponderMove.UciString()
+			g.Board.MakeMove(ponderMove)
+			startPonder(userCommand.Session, g.Board, options)
 		}
-		move := engine.Search(g.Board)
-		stringMove := engine.SquareMap[move.From] + engine.SquareMap[move.To]
-		g.Board.MakeMove(move)
-		boardString := engine.FormatBoard(g.Board)
-		c.IndentedJSON(http.StatusOK, gin.H{"board":boardString,"bestmove":stringMove})
+		c.IndentedJSON(http.StatusOK, response)
 		return
 	}else {
 		c.JSON(http.StatusBadRequest, gin.H{"error":"invalid position command"})
@@ -106,4 +133,52 @@ func Command(c *gin.Context){
 
 	boardString := engine.FormatBoard(g.Board)
 	c.IndentedJSON(http.StatusOK, gin.H{"board":boardString})
+}
+
+// illegalMove answers a rejected move with the reason code and message of the board
+func illegalMove(c *gin.Context, err error) {
+	response := gin.H{"error": "illegal move"}
+	var illegal *engine.IllegalMoveError
+	if errors.As(err, &illegal) {
+		response["move"] = illegal.Move
+		response["reason"] = illegal.Reason
+		response["message"] = illegal.Message
+	}
+	c.JSON(http.StatusBadRequest, response)
+}
+
+// analysisLines converts ranked search lines into their API representation
+func analysisLines(lines []engine.SearchLine) []model.AnalysisLine {
+	result := make([]model.AnalysisLine, len(lines))
+	for i, line := range lines {
+		pv := make([]string, len(line.Pv))
+		for j, move := range line.Pv {
+			pv[j] = move.UciString()
+		}
+		result[i] = model.AnalysisLine{Rank: i+1, Move: line.Move.UciString(), Score: line.Score, Depth: line.Depth, Pv: pv}
+	}
+	return result
+}
+
+// contemptFor chooses the contempt of the engine for the opponent of the request.
+// Humans get a contempt unless overridden, draws against bots follow the bot_draws policy.
+func contemptFor(userCommand model.UciCommand) (int, error) {
+	switch userCommand.Opponent {
+	case "", "human":
+		if userCommand.Contempt == nil {
+			return humanContempt, nil
+		}
+		if *userCommand.Contempt < -maxContempt || *userCommand.Contempt > maxContempt {
+			return 0, errors.New("invalid contempt")
+		}
+		return *userCommand.Contempt, nil
+	case "bot":
+		contempt, ok := botDrawPolicies[userCommand.BotDraws]
+		if !ok {
+			return 0, errors.New("invalid bot_draws policy")
+		}
+		return contempt, nil
+	}
+
+	return 0, errors.New("invalid opponent")
 }
\ No newline at end of file
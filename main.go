@@ -4,10 +4,20 @@ import (
 	"log"
 	"os"
 
+	"github.com/logantwalker/gopher-chess-api/domain/engine"
 	"github.com/logantwalker/gopher-chess-api/router"
 )
 
 func main(){
+	// Load the evaluation profiles, requests choose one by name.
+	if dir := os.Getenv("EVAL_PROFILES"); dir != "" {
+		names, err := engine.LoadEvalProfiles(dir)
+		if err != nil {
+			log.Fatalf("loading evaluation profiles: %s", err)
+		}
+		log.Printf("loaded evaluation profiles %v", names)
+	}
+
 	r := router.InitRouter()
 
 	// Determine port for HTTP service.
@@ -3,4 +3,20 @@ package model
 type UciCommand struct {
 	UciString string `json:"uci_string"`
 	Moves []string `json:"moves"`
+	MultiPV int `json:"multipv"`
+	Session string `json:"session"`
+	Ponder bool `json:"ponder"`
+	Level *int `json:"level"`
+	Contempt *int `json:"contempt"`
+	Opponent string `json:"opponent"`
+	BotDraws string `json:"bot_draws"`
+	EvalProfile string `json:"eval_profile"`
+}
+
+type AnalysisLine struct {
+	Rank int `json:"rank"`
+	Move string `json:"move"`
+	Score int `json:"score"`
+	Depth int `json:"depth"`
+	Pv []string `json:"pv"`
 }
\ No newline at end of file